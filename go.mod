@@ -1,6 +1,6 @@
 module github.com/hydralang/ptk
 
-go 1.13
+go 1.16
 
 require (
 	github.com/klmitch/kent v1.0.3
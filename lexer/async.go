@@ -14,19 +14,103 @@
 
 package lexer
 
+import (
+	"github.com/hydralang/ptk/scanner"
+)
+
+// errLexer is implemented by lexers, such as Lexer, that can report
+// the error that stopped them.
+type errLexer interface {
+	// Err returns the error that stopped the lexer, if any.
+	Err() error
+}
+
+// AsyncLexer is an implementation of ILexer that runs another lexer
+// in a separate goroutine.  It is constructed by NewAsyncLexer.
+type AsyncLexer struct {
+	q   *ChanLexer // The queue of tokens from the goroutine
+	err error      // Error that stopped the goroutine
+}
+
 // NewAsyncLexer wraps another lexer and uses the ChanLexer to allow
-// running that other lexer in a separate goroutine.
-func NewAsyncLexer(ts ILexer) ILexer {
-	// Construct the ChanLexer
-	obj := NewChanLexer()
+// running that other lexer in a separate goroutine.  If a context is
+// passed with the Context option, the goroutine exits once the
+// context is cancelled, and the returned lexer then returns nil; the
+// reason may be retrieved using the Err method.
+func NewAsyncLexer(ts ILexer, options ...AsyncOption) *AsyncLexer {
+	// Process the options
+	opts := &asyncOptions{}
+	for _, opt := range options {
+		opt.asyncApply(opts)
+	}
+
+	// Construct the AsyncLexer
+	obj := &AsyncLexer{
+		q: NewChanLexer(),
+	}
+
+	// Select the cancellation channel; a nil channel is never
+	// ready
+	var done <-chan struct{}
+	if opts.ctx != nil {
+		done = opts.ctx.Done()
+	}
 
 	// Run the other lexer in a goroutine and push all its tokens
 	go func() {
-		for tok := ts.Next(); tok != nil; tok = ts.Next() {
-			obj.Push(tok)
+		defer obj.q.Done()
+
+		var last *Token
+		for {
+			// Stop if cancelled
+			select {
+			case <-done:
+				obj.cancelled(opts.ctx.Err(), last)
+				return
+			default:
+			}
+
+			tok := ts.Next()
+			if tok == nil {
+				if el, ok := ts.(errLexer); ok {
+					obj.err = el.Err()
+				}
+				return
+			}
+
+			select {
+			case obj.q.Chan <- tok:
+				last = tok
+			case <-done:
+				obj.cancelled(opts.ctx.Err(), last)
+				return
+			}
 		}
-		obj.Done()
 	}()
 
 	return obj
 }
+
+// cancelled is a helper for NewAsyncLexer that records the context's
+// error, wrapped in a location error giving the location of the last
+// token passed to the consumer.
+func (al *AsyncLexer) cancelled(err error, last *Token) {
+	if last != nil {
+		err = scanner.LocationError(last.Loc, err)
+	}
+	al.err = err
+}
+
+// Next returns the next token.  At the end of the lexer, a nil should
+// be returned.
+func (al *AsyncLexer) Next() *Token {
+	return al.q.Next()
+}
+
+// Err returns the error that stopped the lexer, if any.  This is
+// either the error from the lexer's context, if it was cancelled, or
+// the error reported by the wrapped lexer's Err method, if it has
+// one.  It should only be called after Next has returned nil.
+func (al *AsyncLexer) Err() error {
+	return al.err
+}
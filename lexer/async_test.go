@@ -15,9 +15,14 @@
 package lexer
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/hydralang/ptk/scanner"
 )
 
 func TestNewAsyncLexer(t *testing.T) {
@@ -32,4 +37,67 @@ func TestNewAsyncLexer(t *testing.T) {
 		i++
 	}
 	assert.Equal(t, len(toks), i)
+	assert.NoError(t, result.Err())
+}
+
+type errorLexer struct {
+	ListLexer
+}
+
+func (l *errorLexer) Err() error {
+	return assert.AnError
+}
+
+func TestNewAsyncLexerError(t *testing.T) {
+	ts := &errorLexer{ListLexer: *NewListLexer([]*Token{{}, {}})}
+
+	result := NewAsyncLexer(ts)
+
+	for tok := result.Next(); tok != nil; tok = result.Next() {
+	}
+	assert.Same(t, assert.AnError, result.Err())
+}
+
+type endlessLexer struct {
+	calls chan struct{}
+	loc   scanner.Location
+}
+
+func (l *endlessLexer) Next() *Token {
+	l.calls <- struct{}{}
+	return &Token{Loc: l.loc}
+}
+
+func TestNewAsyncLexerCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	loc := &mockLocation{}
+	ts := &endlessLexer{calls: make(chan struct{}), loc: loc}
+
+	result := NewAsyncLexer(ts, Context(ctx))
+
+	// Let the goroutine fill the channel, then cancel
+	for i := 0; i <= ChanLexerSize; i++ {
+		<-ts.calls
+	}
+	cancel()
+	go func() {
+		for range ts.calls {
+		}
+	}()
+
+	// The goroutine should exit and close the channel
+	done := make(chan struct{})
+	go func() {
+		for result.Next() != nil {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("async lexer goroutine did not exit")
+	}
+	assert.True(t, errors.Is(result.Err(), context.Canceled))
+	assert.Same(t, loc, scanner.LocationOf(result.Err()))
+	close(ts.calls)
 }
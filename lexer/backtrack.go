@@ -16,6 +16,7 @@ package lexer
 
 import (
 	"container/list"
+	"strings"
 
 	"github.com/hydralang/ptk/scanner"
 )
@@ -62,6 +63,63 @@ type IBackTracker interface {
 	BackTrack()
 }
 
+// ILimitBackTracker is an optional interface for a backtracker that
+// can limit the number of characters saved on its backtracking
+// queue.  It is used to implement the MaxBackTrack lexer option.
+type ILimitBackTracker interface {
+	IBackTracker
+
+	// SetLimit sets a limit on the number of characters that may
+	// be saved on the backtracking queue.  Once the limit is
+	// reached, Next reports ErrBackTrackLimit instead of reading
+	// more characters from the source.  A limit of 0 disables the
+	// limit.
+	SetLimit(limit int)
+
+	// Err returns the error that stopped the backtracker from
+	// reading its source, if any.  Once the limit set by SetLimit
+	// has been reached, this is ErrBackTrackLimit, wrapped in a
+	// location error.
+	Err() error
+}
+
+// ITextBackTracker is an optional interface for a backtracker that
+// can report the text and location of the characters consumed since
+// the last call to Accept or BackTrack.  Recognizers may use it to
+// construct tokens.
+type ITextBackTracker interface {
+	IBackTracker
+
+	// Text returns the text of the characters on the backtracking
+	// queue that have been returned by Next; that is, the text
+	// consumed since the last call to Accept or BackTrack.
+	Text() string
+
+	// RawText is similar to Text, except that it returns the
+	// original text of the source input covered by the characters,
+	// without line ending conversion.  If the text is not
+	// available, the boolean return value will be false.
+	RawText() (string, bool)
+
+	// Location returns the location spanning the characters on
+	// the backtracking queue that have been returned by Next.  If
+	// no characters have been returned, it returns nil.
+	Location() (scanner.Location, error)
+}
+
+// ILastBackTracker is an optional interface for a backtracker that
+// can report the character most recently read from its source.  It
+// is used to locate the error reported when a lexer is cancelled.
+type ILastBackTracker interface {
+	IBackTracker
+
+	// Last returns the character most recently read from the
+	// source scanner by Next; characters that have only been
+	// peeked at are not included.  If no characters have been
+	// read, it returns a zero Char.
+	Last() scanner.Char
+}
+
 // btElem is a struct type containing the returned character and error
 // from the source scanner.
 type btElem struct {
@@ -72,14 +130,24 @@ type btElem struct {
 // BackTracker is an implementation of scanner.Scanner that includes
 // backtracking capability.  A BackTracker wraps another
 // scanner.Scanner (including another instance of BackTracker), but
-// provides additional methods for controlling backtracking.
+// provides additional methods for controlling backtracking.  It
+// implements scanner.IPeekScanner, ILimitBackTracker,
+// ITextBackTracker, and ILastBackTracker.  If the source is a
+// scanner.IResumableScanner, such as a scanner.InteractiveScanner,
+// an EOF from the source is not treated as final; the BackTracker
+// resumes reading from the source once the source is ready again.
 type BackTracker struct {
-	Src   scanner.Scanner // The source scanner
-	max   int             // Maximum length to backtrack by
-	saved *list.List      // Saved characters
-	next  *list.Element   // Next character to return
-	pos   int             // Position within the saved characters
-	last  btElem          // Last return from source
+	Src    scanner.Scanner           // The source scanner
+	text   scanner.TextScanner       // The source scanner, if it provides text
+	rs     scanner.IResumableScanner // The source scanner, if it can resume
+	max    int                       // Maximum length to backtrack by
+	saved  *list.List                // Saved characters
+	next   *list.Element             // Next character to return
+	pos    int                       // Position within the saved characters
+	last   btElem                    // Last return from source
+	peeked []btElem                  // Characters peeked at from the source
+	limit  int                       // Limit on the number of saved characters
+	recent scanner.Char              // Last character read from the source
 }
 
 // NewBackTracker wraps another scanner (which may also be a
@@ -87,8 +155,13 @@ type BackTracker struct {
 // indicates the maximum number of characters to track; use 0 to track
 // no characters, and TrackAll to track all characters.
 func NewBackTracker(src scanner.Scanner, max int) *BackTracker {
+	text, _ := src.(scanner.TextScanner)
+	rs, _ := src.(scanner.IResumableScanner)
+
 	return &BackTracker{
 		Src:   src,
+		text:  text,
+		rs:    rs,
 		max:   max,
 		saved: &list.List{},
 		last: btElem{
@@ -112,7 +185,18 @@ func (bt *BackTracker) Next() (ch scanner.Char, err error) {
 
 	// Need to get a new one from the source
 	if bt.Src != nil {
-		ch, err = bt.Src.Next()
+		ch, err = bt.read()
+		bt.recent = ch
+
+		// Enforce the limit on saved characters
+		if bt.limit > 0 && bt.max != 0 && bt.saved.Len() >= bt.limit && ch.Rune != scanner.EOF {
+			bt.Src = nil
+			bt.last = btElem{
+				ch:  scanner.Char{Rune: scanner.EOF, Loc: ch.Loc},
+				err: scanner.LocationError(ch.Loc, ErrBackTrackLimit),
+			}
+			return bt.last.ch, bt.last.err
+		}
 
 		// Save if we need to
 		if bt.max != 0 {
@@ -130,7 +214,9 @@ func (bt *BackTracker) Next() (ch scanner.Char, err error) {
 
 			// See if the source is exhausted
 			if ch.Rune == scanner.EOF {
-				bt.Src = nil
+				if bt.rs == nil {
+					bt.Src = nil
+				}
 				bt.last = btElem{
 					ch: ch,
 				}
@@ -141,14 +227,88 @@ func (bt *BackTracker) Next() (ch scanner.Char, err error) {
 	}
 
 	// No data to return
-	return bt.last.ch, nil
+	return bt.last.ch, bt.last.err
+}
+
+// read is a helper for Next that reads a character from the source.
+// Characters that have been peeked at are returned first.
+func (bt *BackTracker) read() (scanner.Char, error) {
+	bt.resume()
+	if len(bt.peeked) > 0 {
+		elem := bt.peeked[0]
+		bt.peeked = bt.peeked[1:]
+		return elem.ch, elem.err
+	}
+
+	return bt.Src.Next()
+}
+
+// resume is a helper for read and Peek that discards an EOF peeked at
+// from a resumable source once the source is ready again.
+func (bt *BackTracker) resume() {
+	l := len(bt.peeked)
+	if bt.rs != nil && l > 0 && bt.peeked[l-1].ch.Rune == scanner.EOF && bt.rs.Ready() {
+		bt.peeked = bt.peeked[:l-1]
+	}
+}
+
+// Peek returns the nth upcoming character without consuming it, along
+// with the error, if any, that Next will return with it.  Peek(0)
+// returns the character that the next call to Next will return.  Once
+// the source has been exhausted, Peek returns the final EOF
+// character.  If n is negative, Peek returns an EOF character with
+// scanner.ErrPeekOffset.  Peeking does not alter the backtracking
+// queue.
+func (bt *BackTracker) Peek(n int) (scanner.Char, error) {
+	if n < 0 {
+		return scanner.Char{Rune: scanner.EOF}, scanner.ErrPeekOffset
+	}
+
+	// Look for the character among the saved characters
+	for e := bt.next; e != nil; e = e.Next() {
+		if n == 0 {
+			return e.Value.(btElem).ch, e.Value.(btElem).err
+		}
+		n--
+	}
+
+	// Read enough characters from the source
+	if bt.Src == nil {
+		return bt.last.ch, bt.last.err
+	}
+	bt.resume()
+	for len(bt.peeked) <= n {
+		if l := len(bt.peeked); l > 0 && bt.peeked[l-1].ch.Rune == scanner.EOF {
+			return bt.peeked[l-1].ch, nil
+		}
+
+		ch, err := bt.Src.Next()
+		bt.peeked = append(bt.peeked, btElem{
+			ch:  ch,
+			err: err,
+		})
+	}
+
+	return bt.peeked[n].ch, bt.peeked[n].err
 }
 
 // More is used to determine if there are any more characters
 // available for Next to return, given the current state of the
-// BackTracker.
+// BackTracker.  For a resumable source, this is false once the source
+// has returned EOF, until the source is ready again.
 func (bt *BackTracker) More() bool {
-	return bt.next != nil || bt.Src != nil
+	switch {
+	case bt.next != nil:
+		return true
+	case bt.Src == nil:
+		return false
+	case bt.rs == nil:
+		return true
+	case len(bt.peeked) > 0 && bt.peeked[0].ch.Rune != scanner.EOF:
+		return true
+	}
+
+	return bt.rs.Ready()
 }
 
 // SetMax allows updating the maximum number of characters to allow
@@ -171,6 +331,21 @@ func (bt *BackTracker) SetMax(max int) {
 	}
 }
 
+// SetLimit sets a limit on the number of characters that may be saved
+// on the backtracking queue.  Once the limit is reached, Next reports
+// ErrBackTrackLimit instead of reading more characters from the
+// source.  A limit of 0 disables the limit.
+func (bt *BackTracker) SetLimit(limit int) {
+	bt.limit = limit
+}
+
+// Err returns the error that stopped the BackTracker from reading its
+// source, if any.  Once the limit set by SetLimit has been reached,
+// this is ErrBackTrackLimit, wrapped in a location error.
+func (bt *BackTracker) Err() error {
+	return bt.last.err
+}
+
 // Accept accepts characters from the backtracking queue, leaving only
 // the specified number of characters on the queue.
 func (bt *BackTracker) Accept(leave int) {
@@ -229,3 +404,75 @@ func (bt *BackTracker) BackTrack() {
 	bt.next = bt.saved.Front()
 	bt.pos = 0
 }
+
+// consumed is a helper for RawText and Location that returns the first
+// and last characters on the backtracking queue that have been
+// returned by Next.  If no characters have been returned, the
+// boolean return value will be false.
+func (bt *BackTracker) consumed() (scanner.Char, scanner.Char, bool) {
+	first := bt.saved.Front()
+	if first == nil || first == bt.next {
+		return scanner.Char{}, scanner.Char{}, false
+	}
+
+	last := bt.saved.Back()
+	if bt.next != nil {
+		last = bt.next.Prev()
+	}
+
+	return first.Value.(btElem).ch, last.Value.(btElem).ch, true
+}
+
+// Text returns the text of the characters on the backtracking queue
+// that have been returned by Next; that is, the text consumed since
+// the last call to Accept or BackTrack.
+func (bt *BackTracker) Text() string {
+	buf := &strings.Builder{}
+	for e := bt.saved.Front(); e != nil && e != bt.next; e = e.Next() {
+		if ch := e.Value.(btElem).ch; ch.Rune != scanner.EOF {
+			buf.WriteRune(ch.Rune)
+		}
+	}
+
+	return buf.String()
+}
+
+// RawText is similar to Text, except that it returns the original
+// text of the source input covered by the characters, without line
+// ending conversion.  The text is only available if the source
+// scanner is a scanner.TextScanner, such as a scanner.MemoryScanner,
+// in which case it is taken from the source input without copying;
+// otherwise, the boolean return value will be false.
+func (bt *BackTracker) RawText() (string, bool) {
+	if bt.text == nil {
+		return "", false
+	}
+
+	first, last, ok := bt.consumed()
+	if !ok {
+		return "", true
+	} else if first.Loc == nil || last.Loc == nil {
+		return "", false
+	}
+
+	return bt.text.TextSpan(first.Loc, last.Loc)
+}
+
+// Location returns the location spanning the characters on the
+// backtracking queue that have been returned by Next.  If no
+// characters have been returned, it returns nil.
+func (bt *BackTracker) Location() (scanner.Location, error) {
+	first, last, ok := bt.consumed()
+	if !ok || first.Loc == nil {
+		return nil, nil
+	}
+
+	return first.Loc.ThruEnd(last.Loc)
+}
+
+// Last returns the character most recently read from the source
+// scanner by Next; characters that have only been peeked at are not
+// included.  If no characters have been read, it returns a zero Char.
+func (bt *BackTracker) Last() scanner.Char {
+	return bt.recent
+}
@@ -16,10 +16,13 @@ package lexer
 
 import (
 	"container/list"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/hydralang/ptk/scanner"
 )
@@ -38,10 +41,40 @@ func (m *mockScanner) Next() (scanner.Char, error) {
 	return scanner.Char{}, args.Error(1)
 }
 
+type mockTextScanner struct {
+	mockScanner
+}
+
+func (m *mockTextScanner) TextSpan(first, last scanner.Location) (string, bool) {
+	args := m.MethodCalled("TextSpan", first, last)
+
+	return args.String(0), args.Bool(1)
+}
+
+type mockResumableScanner struct {
+	mockScanner
+}
+
+func (m *mockResumableScanner) Ready() bool {
+	args := m.MethodCalled("Ready")
+
+	return args.Bool(0)
+}
+
 type mockBackTracker struct {
 	mockScanner
 }
 
+func (m *mockBackTracker) Peek(n int) (scanner.Char, error) {
+	args := m.MethodCalled("Peek", n)
+
+	if tmp := args.Get(0); tmp != nil {
+		return tmp.(scanner.Char), args.Error(1)
+	}
+
+	return scanner.Char{}, args.Error(1)
+}
+
 func (m *mockBackTracker) More() bool {
 	args := m.MethodCalled("More")
 
@@ -52,6 +85,16 @@ func (m *mockBackTracker) SetMax(max int) {
 	m.MethodCalled("SetMax", max)
 }
 
+func (m *mockBackTracker) SetLimit(limit int) {
+	m.MethodCalled("SetLimit", limit)
+}
+
+func (m *mockBackTracker) Err() error {
+	args := m.MethodCalled("Err")
+
+	return args.Error(0)
+}
+
 func (m *mockBackTracker) Accept(leave int) {
 	m.MethodCalled("Accept", leave)
 }
@@ -72,10 +115,64 @@ func (m *mockBackTracker) BackTrack() {
 	m.MethodCalled("BackTrack")
 }
 
+func (m *mockBackTracker) Text() string {
+	args := m.MethodCalled("Text")
+
+	return args.String(0)
+}
+
+func (m *mockBackTracker) RawText() (string, bool) {
+	args := m.MethodCalled("RawText")
+
+	return args.String(0), args.Bool(1)
+}
+
+func (m *mockBackTracker) Last() scanner.Char {
+	args := m.MethodCalled("Last")
+
+	if tmp := args.Get(0); tmp != nil {
+		return tmp.(scanner.Char)
+	}
+
+	return scanner.Char{}
+}
+
+func (m *mockBackTracker) Location() (scanner.Location, error) {
+	args := m.MethodCalled("Location")
+
+	if tmp := args.Get(0); tmp != nil {
+		return tmp.(scanner.Location), args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+// basicBackTracker wraps a backtracker, hiding any methods not in
+// IBackTracker.
+type basicBackTracker struct {
+	IBackTracker
+}
+
 func TestBackTrackerImplementsIBackTracker(t *testing.T) {
 	assert.Implements(t, (*IBackTracker)(nil), &BackTracker{})
 }
 
+func TestBackTrackerImplementsIPeekScanner(t *testing.T) {
+	assert.Implements(t, (*scanner.IPeekScanner)(nil), &BackTracker{})
+}
+
+func TestBackTrackerImplementsILimitBackTracker(t *testing.T) {
+	assert.Implements(t, (*ILimitBackTracker)(nil), &BackTracker{})
+}
+
+func TestBackTrackerImplementsITextBackTracker(t *testing.T) {
+	assert.Implements(t, (*ITextBackTracker)(nil), &BackTracker{})
+}
+
+func TestBackTrackerImplementsILastBackTracker(t *testing.T) {
+	assert.Implements(t, (*ILastBackTracker)(nil), &BackTracker{})
+}
+
 func TestNewBackTracker(t *testing.T) {
 	src := &mockScanner{}
 
@@ -91,6 +188,66 @@ func TestNewBackTracker(t *testing.T) {
 	}, result)
 }
 
+func TestNewBackTrackerTextScanner(t *testing.T) {
+	src := &mockTextScanner{}
+
+	result := NewBackTracker(src, 42)
+
+	assert.Equal(t, &BackTracker{
+		Src:   src,
+		text:  src,
+		max:   42,
+		saved: &list.List{},
+		last: btElem{
+			ch: scanner.Char{Rune: scanner.EOF},
+		},
+	}, result)
+}
+
+func TestNewBackTrackerResumable(t *testing.T) {
+	src := &mockResumableScanner{}
+
+	result := NewBackTracker(src, 42)
+
+	assert.Equal(t, &BackTracker{
+		Src:   src,
+		rs:    src,
+		max:   42,
+		saved: &list.List{},
+		last: btElem{
+			ch: scanner.Char{Rune: scanner.EOF},
+		},
+	}, result)
+}
+
+func TestBackTrackerNextResumableEOF(t *testing.T) {
+	src := &mockResumableScanner{}
+	src.On("Next").Return(scanner.Char{Rune: scanner.EOF}, nil)
+	obj := NewBackTracker(src, TrackAll)
+
+	result, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, scanner.EOF, result.Rune)
+	assert.Same(t, src, obj.Src)
+	src.AssertExpectations(t)
+}
+
+func TestBackTrackerNextResumedPeek(t *testing.T) {
+	src := &mockResumableScanner{}
+	src.On("Ready").Return(true)
+	src.On("Next").Return(scanner.Char{Rune: 'a'}, nil)
+	obj := NewBackTracker(src, TrackAll)
+	obj.peeked = []btElem{{ch: scanner.Char{Rune: scanner.EOF}}}
+
+	result, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 'a', result.Rune)
+	assert.Len(t, obj.peeked, 0)
+	src.AssertExpectations(t)
+}
+
 func TestBackTrackerNextBase(t *testing.T) {
 	src := &mockScanner{}
 	src.On("Next").Return(scanner.Char{Rune: 't'}, assert.AnError)
@@ -298,6 +455,173 @@ func TestBackTrackerNextExtension(t *testing.T) {
 	}, obj.last)
 }
 
+func TestBackTrackerNextPeeked(t *testing.T) {
+	src := &mockScanner{}
+	obj := &BackTracker{
+		Src:   src,
+		max:   TrackAll,
+		saved: &list.List{},
+		peeked: []btElem{
+			{ch: scanner.Char{Rune: 't'}, err: assert.AnError},
+			{ch: scanner.Char{Rune: 'u'}},
+		},
+	}
+
+	result, err := obj.Next()
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, scanner.Char{Rune: 't'}, result)
+	assert.Equal(t, 1, obj.saved.Len())
+	assert.Equal(t, 1, obj.pos)
+	assert.Equal(t, []btElem{{ch: scanner.Char{Rune: 'u'}}}, obj.peeked)
+	src.AssertExpectations(t)
+}
+
+func TestBackTrackerPeekSaved(t *testing.T) {
+	src := &mockScanner{}
+	obj := &BackTracker{
+		Src:   src,
+		max:   TrackAll,
+		saved: &list.List{},
+	}
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 'a'}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 'b'}, err: assert.AnError})
+	obj.next = obj.saved.Front()
+
+	result, err := obj.Peek(1)
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, scanner.Char{Rune: 'b'}, result)
+	assert.Same(t, obj.saved.Front(), obj.next)
+	assert.Nil(t, obj.peeked)
+	src.AssertExpectations(t)
+}
+
+func TestBackTrackerPeekSource(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(scanner.Char{Rune: 'c'}, nil).Once()
+	src.On("Next").Return(scanner.Char{Rune: 'd'}, assert.AnError).Once()
+	obj := &BackTracker{
+		Src:   src,
+		max:   TrackAll,
+		saved: &list.List{},
+	}
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 'a'}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 'b'}})
+	obj.next = obj.saved.Back()
+
+	result, err := obj.Peek(2)
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, scanner.Char{Rune: 'd'}, result)
+	assert.Same(t, obj.saved.Back(), obj.next)
+	assert.Equal(t, 2, obj.saved.Len())
+	assert.Equal(t, []btElem{
+		{ch: scanner.Char{Rune: 'c'}},
+		{ch: scanner.Char{Rune: 'd'}, err: assert.AnError},
+	}, obj.peeked)
+	src.AssertExpectations(t)
+}
+
+func TestBackTrackerPeekPeeked(t *testing.T) {
+	src := &mockScanner{}
+	obj := &BackTracker{
+		Src:   src,
+		max:   TrackAll,
+		saved: &list.List{},
+		peeked: []btElem{
+			{ch: scanner.Char{Rune: 'c'}},
+			{ch: scanner.Char{Rune: 'd'}},
+		},
+	}
+
+	result, err := obj.Peek(1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, scanner.Char{Rune: 'd'}, result)
+	assert.Len(t, obj.peeked, 2)
+	src.AssertExpectations(t)
+}
+
+func TestBackTrackerPeekPastEOF(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(scanner.Char{Rune: scanner.EOF}, assert.AnError).Once()
+	obj := &BackTracker{
+		Src:   src,
+		max:   TrackAll,
+		saved: &list.List{},
+	}
+
+	result, err := obj.Peek(3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, scanner.Char{Rune: scanner.EOF}, result)
+	assert.Equal(t, []btElem{
+		{ch: scanner.Char{Rune: scanner.EOF}, err: assert.AnError},
+	}, obj.peeked)
+	src.AssertExpectations(t)
+}
+
+func TestBackTrackerPeekExhausted(t *testing.T) {
+	obj := &BackTracker{
+		max:   TrackAll,
+		saved: &list.List{},
+		last: btElem{
+			ch: scanner.Char{Rune: scanner.EOF},
+		},
+	}
+
+	result, err := obj.Peek(0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, scanner.Char{Rune: scanner.EOF}, result)
+}
+
+func TestBackTrackerPeekNegative(t *testing.T) {
+	src := &mockScanner{}
+	obj := &BackTracker{
+		Src:   src,
+		max:   TrackAll,
+		saved: &list.List{},
+	}
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 'a'}})
+	obj.next = obj.saved.Front()
+
+	result, err := obj.Peek(-1)
+
+	assert.Same(t, scanner.ErrPeekOffset, err)
+	assert.Equal(t, scanner.Char{Rune: scanner.EOF}, result)
+	assert.Nil(t, obj.peeked)
+	src.AssertExpectations(t)
+}
+
+func TestBackTrackerPeekWindow(t *testing.T) {
+	src := scanner.NewListScanner([]scanner.Char{
+		{Rune: 'a'},
+		{Rune: 'b'},
+		{Rune: 'c'},
+		{Rune: scanner.EOF},
+	}, nil)
+	obj := NewBackTracker(src, TrackAll)
+
+	ch, _ := obj.Next()
+	assert.Equal(t, 'a', ch.Rune)
+	ch, _ = obj.Peek(1)
+	assert.Equal(t, 'c', ch.Rune)
+	assert.Equal(t, 1, obj.Len())
+	assert.Equal(t, 0, obj.Pos())
+	obj.BackTrack()
+	ch, _ = obj.Peek(0)
+	assert.Equal(t, 'a', ch.Rune)
+	ch, _ = obj.Peek(1)
+	assert.Equal(t, 'b', ch.Rune)
+	for _, r := range []rune{'a', 'b', 'c'} {
+		ch, _ = obj.Next()
+		assert.Equal(t, r, ch.Rune)
+	}
+	assert.Equal(t, 3, obj.Len())
+}
+
 func TestBackTrackerMoreBackTracked(t *testing.T) {
 	obj := &BackTracker{
 		saved: &list.List{},
@@ -321,6 +645,34 @@ func TestBackTrackerMoreHaveSrc(t *testing.T) {
 	assert.True(t, result)
 }
 
+func TestBackTrackerMoreResumable(t *testing.T) {
+	src := &mockResumableScanner{}
+	src.On("Ready").Return(false).Once()
+	src.On("Ready").Return(true).Once()
+	obj := &BackTracker{
+		Src: src,
+		rs:  src,
+	}
+
+	assert.False(t, obj.More())
+	assert.True(t, obj.More())
+	src.AssertExpectations(t)
+}
+
+func TestBackTrackerMoreResumablePeeked(t *testing.T) {
+	src := &mockResumableScanner{}
+	obj := &BackTracker{
+		Src:    src,
+		rs:     src,
+		peeked: []btElem{{ch: scanner.Char{Rune: 'a'}}},
+	}
+
+	result := obj.More()
+
+	assert.True(t, result)
+	src.AssertExpectations(t)
+}
+
 func TestBackTrackerMoreNoMore(t *testing.T) {
 	obj := &BackTracker{}
 
@@ -402,6 +754,88 @@ func TestBackTrackerSetMaxDecrease(t *testing.T) {
 	assert.Equal(t, 2, obj.pos)
 }
 
+func TestBackTrackerSetLimit(t *testing.T) {
+	obj := &BackTracker{}
+
+	obj.SetLimit(42)
+
+	assert.Equal(t, 42, obj.limit)
+}
+
+func TestBackTrackerErrBase(t *testing.T) {
+	obj := &BackTracker{
+		last: btElem{
+			ch: scanner.Char{Rune: scanner.EOF},
+		},
+	}
+
+	result := obj.Err()
+
+	assert.NoError(t, result)
+}
+
+func TestBackTrackerErrLimit(t *testing.T) {
+	obj := &BackTracker{
+		last: btElem{
+			ch:  scanner.Char{Rune: scanner.EOF},
+			err: ErrBackTrackLimit,
+		},
+	}
+
+	result := obj.Err()
+
+	assert.Same(t, ErrBackTrackLimit, result)
+}
+
+func TestBackTrackerNextLimit(t *testing.T) {
+	loc := &mockLocation{}
+	src := &mockScanner{}
+	src.On("Next").Return(scanner.Char{Rune: 't', Loc: loc}, nil).Once()
+	obj := &BackTracker{
+		Src:   src,
+		max:   TrackAll,
+		saved: &list.List{},
+		limit: 2,
+	}
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 'a'}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 'b'}})
+	obj.pos = 2
+
+	result, err := obj.Next()
+
+	assert.True(t, errors.Is(err, ErrBackTrackLimit))
+	assert.Same(t, loc, scanner.LocationOf(err))
+	assert.Equal(t, scanner.Char{Rune: scanner.EOF, Loc: loc}, result)
+	assert.Nil(t, obj.Src)
+	assert.Equal(t, 2, obj.saved.Len())
+	assert.Equal(t, btElem{ch: result, err: err}, obj.last)
+	assert.False(t, obj.More())
+	result2, err2 := obj.Next()
+	assert.Equal(t, result, result2)
+	assert.Equal(t, err, err2)
+	src.AssertExpectations(t)
+}
+
+func TestBackTrackerNextUnderLimit(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(scanner.Char{Rune: 't'}, nil).Once()
+	obj := &BackTracker{
+		Src:   src,
+		max:   TrackAll,
+		saved: &list.List{},
+		limit: 2,
+	}
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 'a'}})
+	obj.pos = 1
+
+	result, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, scanner.Char{Rune: 't'}, result)
+	assert.Equal(t, 2, obj.saved.Len())
+	src.AssertExpectations(t)
+}
+
 func TestBackTrackerAcceptUnsaved(t *testing.T) {
 	obj := &BackTracker{
 		max:   0,
@@ -573,3 +1007,308 @@ func TestBackTrackerBackTrack(t *testing.T) {
 	assert.Same(t, obj.saved.Front(), obj.next)
 	assert.Equal(t, 0, obj.pos)
 }
+
+func TestBackTrackerTextBase(t *testing.T) {
+	obj := &BackTracker{
+		saved: &list.List{},
+	}
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 't'}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 'e'}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 's'}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 't'}})
+	obj.next = obj.saved.Back()
+
+	result := obj.Text()
+
+	assert.Equal(t, "tes", result)
+}
+
+func TestBackTrackerTextAll(t *testing.T) {
+	obj := &BackTracker{
+		saved: &list.List{},
+	}
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 't'}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 'e'}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 's'}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 't'}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: scanner.EOF}})
+
+	result := obj.Text()
+
+	assert.Equal(t, "test", result)
+}
+
+func TestBackTrackerTextEmpty(t *testing.T) {
+	obj := &BackTracker{
+		saved: &list.List{},
+	}
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 't'}})
+	obj.next = obj.saved.Front()
+
+	result := obj.Text()
+
+	assert.Equal(t, "", result)
+}
+
+func TestBackTrackerTextSource(t *testing.T) {
+	src := &mockTextScanner{}
+	obj := &BackTracker{
+		text:  src,
+		saved: &list.List{},
+	}
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 't', Loc: &mockLocation{}}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: '\n', Loc: &mockLocation{}}})
+
+	result := obj.Text()
+
+	assert.Equal(t, "t\n", result)
+	src.AssertExpectations(t)
+}
+
+func TestBackTrackerRawTextBase(t *testing.T) {
+	loc1 := &mockLocation{}
+	loc2 := &mockLocation{}
+	src := &mockTextScanner{}
+	src.On("TextSpan", loc1, loc2).Return("t\r\n", true)
+	obj := &BackTracker{
+		text:  src,
+		saved: &list.List{},
+	}
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 't', Loc: loc1}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: '\n', Loc: loc2}})
+
+	result, ok := obj.RawText()
+
+	assert.True(t, ok)
+	assert.Equal(t, "t\r\n", result)
+	src.AssertExpectations(t)
+}
+
+func TestBackTrackerRawTextUnavailable(t *testing.T) {
+	loc1 := &mockLocation{}
+	loc2 := &mockLocation{}
+	src := &mockTextScanner{}
+	src.On("TextSpan", loc1, loc2).Return("", false)
+	obj := &BackTracker{
+		text:  src,
+		saved: &list.List{},
+	}
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 't', Loc: loc1}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 'e', Loc: loc2}})
+
+	result, ok := obj.RawText()
+
+	assert.False(t, ok)
+	assert.Equal(t, "", result)
+	src.AssertExpectations(t)
+}
+
+func TestBackTrackerRawTextPartial(t *testing.T) {
+	loc1 := &mockLocation{}
+	loc2 := &mockLocation{}
+	loc3 := &mockLocation{}
+	src := &mockTextScanner{}
+	src.On("TextSpan", loc1, loc2).Return("te", true)
+	obj := &BackTracker{
+		text:  src,
+		saved: &list.List{},
+	}
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 't', Loc: loc1}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 'e', Loc: loc2}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 's', Loc: loc3}})
+	obj.next = obj.saved.Back()
+
+	result, ok := obj.RawText()
+
+	assert.True(t, ok)
+	assert.Equal(t, "te", result)
+	src.AssertExpectations(t)
+}
+
+func TestBackTrackerRawTextEmpty(t *testing.T) {
+	src := &mockTextScanner{}
+	obj := &BackTracker{
+		text:  src,
+		saved: &list.List{},
+	}
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 't', Loc: &mockLocation{}}})
+	obj.next = obj.saved.Front()
+
+	result, ok := obj.RawText()
+
+	assert.True(t, ok)
+	assert.Equal(t, "", result)
+	src.AssertExpectations(t)
+}
+
+func TestBackTrackerRawTextNoLocation(t *testing.T) {
+	src := &mockTextScanner{}
+	obj := &BackTracker{
+		text:  src,
+		saved: &list.List{},
+	}
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 't'}})
+
+	result, ok := obj.RawText()
+
+	assert.False(t, ok)
+	assert.Equal(t, "", result)
+	src.AssertExpectations(t)
+}
+
+func TestBackTrackerRawTextNoSource(t *testing.T) {
+	obj := &BackTracker{
+		saved: &list.List{},
+	}
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 't', Loc: &mockLocation{}}})
+
+	result, ok := obj.RawText()
+
+	assert.False(t, ok)
+	assert.Equal(t, "", result)
+}
+
+func TestBackTrackerRawTextAllocations(t *testing.T) {
+	obj := NewBackTracker(scanner.NewStringScanner("te\r\nst", scanner.FileLocation{
+		B: scanner.FilePos{L: 1, C: 1},
+		E: scanner.FilePos{L: 1, C: 1},
+	}), TrackAll)
+	for i := 0; i < 3; i++ {
+		_, err := obj.Next()
+		require.NoError(t, err)
+	}
+
+	result := testing.AllocsPerRun(100, func() {
+		_, _ = obj.RawText()
+	})
+
+	text, ok := obj.RawText()
+	assert.True(t, ok)
+	assert.Equal(t, "te\r\n", text)
+	assert.Equal(t, "te\n", obj.Text())
+	assert.Equal(t, 0.0, result)
+}
+
+func TestBackTrackerLocationBase(t *testing.T) {
+	loc1 := &mockLocation{}
+	loc2 := &mockLocation{}
+	loc3 := &mockLocation{}
+	loc4 := &mockLocation{}
+	span := &mockLocation{}
+	loc1.On("ThruEnd", loc3).Return(span, nil)
+	obj := &BackTracker{
+		saved: &list.List{},
+	}
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 't', Loc: loc1}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 'e', Loc: loc2}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 's', Loc: loc3}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 't', Loc: loc4}})
+	obj.next = obj.saved.Back()
+
+	result, err := obj.Location()
+
+	assert.NoError(t, err)
+	assert.Same(t, span, result)
+	loc1.AssertExpectations(t)
+}
+
+func TestBackTrackerLocationError(t *testing.T) {
+	loc1 := &mockLocation{}
+	loc2 := &mockLocation{}
+	loc1.On("ThruEnd", loc2).Return(nil, assert.AnError)
+	obj := &BackTracker{
+		saved: &list.List{},
+	}
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 't', Loc: loc1}})
+	obj.saved.PushBack(btElem{ch: scanner.Char{Rune: 'e', Loc: loc2}})
+
+	result, err := obj.Location()
+
+	assert.Same(t, assert.AnError, err)
+	assert.Nil(t, result)
+	loc1.AssertExpectations(t)
+}
+
+func TestBackTrackerLocationEmpty(t *testing.T) {
+	obj := &BackTracker{
+		saved: &list.List{},
+	}
+
+	result, err := obj.Location()
+
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestBackTrackerLast(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(scanner.Char{Rune: 'a'}, nil).Once()
+	src.On("Next").Return(scanner.Char{Rune: 'b'}, nil).Once()
+	obj := NewBackTracker(src, TrackAll)
+
+	assert.Equal(t, scanner.Char{}, obj.Last())
+	_, err := obj.Next()
+	require.NoError(t, err)
+	_, err = obj.Peek(0)
+	require.NoError(t, err)
+	assert.Equal(t, scanner.Char{Rune: 'a'}, obj.Last())
+	obj.BackTrack()
+	_, err = obj.Next()
+	require.NoError(t, err)
+	_, err = obj.Next()
+	require.NoError(t, err)
+	assert.Equal(t, scanner.Char{Rune: 'b'}, obj.Last())
+	src.AssertExpectations(t)
+}
+
+func TestBackTrackerRecording(t *testing.T) {
+	src := scanner.NewFileScanner(strings.NewReader("foo bar"), scanner.FileLocation{
+		File: "file",
+		B:    scanner.FilePos{L: 1, C: 1},
+		E:    scanner.FilePos{L: 1, C: 1},
+	})
+	obj := NewBackTracker(src, TrackAll)
+
+	for i := 0; i < 4; i++ {
+		_, err := obj.Next()
+		require.NoError(t, err)
+	}
+	obj.Accept(0)
+	for i := 0; i < 3; i++ {
+		_, err := obj.Next()
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "bar", obj.Text())
+	loc, err := obj.Location()
+	assert.NoError(t, err)
+	assert.Equal(t, scanner.FileLocation{
+		File: "file",
+		B:    scanner.FilePos{L: 1, C: 5, O: 4, R: 4},
+		E:    scanner.FilePos{L: 1, C: 8, O: 7, R: 7},
+	}, loc)
+}
+
+func TestBackTrackerRecordingMemory(t *testing.T) {
+	src := scanner.NewStringScanner("foo bar\r\nbaz", scanner.FileLocation{
+		File: "file",
+		B:    scanner.FilePos{L: 1, C: 1},
+		E:    scanner.FilePos{L: 1, C: 1},
+	})
+	obj := NewBackTracker(src, TrackAll)
+
+	for i := 0; i < 4; i++ {
+		_, err := obj.Next()
+		require.NoError(t, err)
+	}
+	obj.Accept(0)
+	for i := 0; i < 4; i++ {
+		_, err := obj.Next()
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "bar\n", obj.Text())
+	text, ok := obj.RawText()
+	assert.True(t, ok)
+	assert.Equal(t, "bar\r\n", text)
+}
@@ -16,6 +16,7 @@ package lexer
 
 import (
 	"container/list"
+	"context"
 
 	"github.com/hydralang/ptk/scanner"
 )
@@ -30,13 +31,16 @@ type ILexer interface {
 
 // Lexer is an implementation of ILexer.
 type Lexer struct {
-	Scanner IBackTracker // The character source, wrapped in a BackTracker
-	State   State        // The state of the lexer
-	toks    *list.List   // List of tokens to produce
+	Scanner IBackTracker    // The character source, wrapped in a BackTracker
+	State   State           // The state of the lexer
+	toks    *list.List      // List of tokens to produce
+	ctx     context.Context // Context to monitor for cancellation
+	err     error           // Error that stopped the lexer
+	maxTok  int             // Maximum token length
 }
 
 // New constructs a new Lexer using the provided source and state.
-func New(src scanner.Scanner, state State) *Lexer {
+func New(src scanner.Scanner, state State, options ...Option) *Lexer {
 	// Wrap the scanner to allow for backtracking
 	var ok bool
 	var bt IBackTracker
@@ -44,11 +48,18 @@ func New(src scanner.Scanner, state State) *Lexer {
 		bt = NewBackTracker(src, TrackAll)
 	}
 
-	return &Lexer{
+	l := &Lexer{
 		Scanner: bt,
 		State:   state,
 		toks:    &list.List{},
 	}
+
+	// Apply the options
+	for _, opt := range options {
+		opt.lexerApply(l)
+	}
+
+	return l
 }
 
 // next is the actual implementation of the lexer.  This is the
@@ -58,8 +69,13 @@ func (l *Lexer) next() {
 	// Reset the backtracker
 	l.Scanner.SetMax(TrackAll)
 
-	// Classify the contents
+	// Classify the contents; stop trying recognizers if the lexer
+	// is stopped, so that no tokens are produced from characters
+	// left on the backtracking queue
 	for _, rec := range l.State.Classifier().Classify(l) {
+		if l.stopped() {
+			break
+		}
 		l.Scanner.BackTrack()
 		if rec.Recognize(l) {
 			l.Scanner.Accept(0)
@@ -68,18 +84,45 @@ func (l *Lexer) next() {
 	}
 
 	// None of the recognizers recognized the contents
-	l.Scanner.BackTrack()
-	l.State.Classifier().Error(l)
+	if !l.stopped() {
+		l.Scanner.BackTrack()
+		l.State.Classifier().Error(l)
+	}
 	l.Scanner.Accept(0)
 }
 
+// stopped is a helper for Next that checks to see if the lexer has
+// been stopped, either by an error, by the backtracker reaching its
+// limit, or by cancellation of its context.  If the backtracker is an
+// ILastBackTracker, the context's error is wrapped in a location
+// error giving the location of the last character consumed.
+func (l *Lexer) stopped() bool {
+	if l.err == nil {
+		if lbt, ok := l.Scanner.(ILimitBackTracker); ok {
+			l.err = lbt.Err()
+		}
+	}
+	if l.err == nil && l.ctx != nil {
+		if err := l.ctx.Err(); err != nil {
+			var loc scanner.Location
+			if lbt, ok := l.Scanner.(ILastBackTracker); ok {
+				loc = lbt.Last().Loc
+			}
+			l.err = scanner.LocationError(loc, err)
+		}
+	}
+
+	return l.err != nil
+}
+
 // Next returns the next token.  At the end of the lexer, a nil should
 // be returned.
 func (l *Lexer) Next() *Token {
 	// Loop until we have a token or all characters have been
-	// processed
+	// processed; tokens already queued are returned even if the
+	// lexer has been stopped
 	for l.toks.Len() <= 0 {
-		if !l.Scanner.More() {
+		if l.stopped() || !l.Scanner.More() {
 			return nil
 		}
 
@@ -93,10 +136,28 @@ func (l *Lexer) Next() *Token {
 	return l.toks.Front().Value.(*Token)
 }
 
+// Err returns the error that stopped the lexer, if any.  This is the
+// error from the lexer's context, if it was cancelled, wrapped in a
+// location error; ErrTokenTooLong, if a recognizer pushed a token
+// that was too long; or ErrBackTrackLimit, if a recognizer read more
+// characters than allowed by the MaxBackTrack option.  The latter
+// two are also wrapped in location errors.
+func (l *Lexer) Err() error {
+	return l.err
+}
+
 // Push pushes a token onto the list of tokens to be returned by the
 // lexer.  Recognizers should call this method with the token or
-// tokens that they recognize from the input.
+// tokens that they recognize from the input.  It returns false if the
+// token could not be pushed, which happens if the recognizer consumed
+// more characters than allowed by the MaxTokenLength option.
 func (l *Lexer) Push(tok *Token) bool {
+	// Check the token length
+	if l.maxTok > 0 && l.Scanner.Pos()+1 > l.maxTok {
+		l.err = scanner.LocationError(tok.Loc, ErrTokenTooLong)
+		return false
+	}
+
 	l.toks.PushBack(tok)
 	return true
 }
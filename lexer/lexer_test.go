@@ -16,11 +16,16 @@ package lexer
 
 import (
 	"container/list"
+	"context"
+	"errors"
 	"testing"
+	"unicode"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+
+	"github.com/hydralang/ptk/scanner"
 )
 
 func TestLexerImplementsILexer(t *testing.T) {
@@ -51,6 +56,27 @@ func TestNewWithBackTracker(t *testing.T) {
 	assert.Equal(t, &list.List{}, result.toks)
 }
 
+func TestNewWithBasicBackTracker(t *testing.T) {
+	src := basicBackTracker{&mockBackTracker{}}
+	state := &mockState{}
+
+	result := New(src, state)
+
+	assert.Equal(t, src, result.Scanner)
+	assert.Same(t, state, result.State)
+}
+
+func TestNewWithOptions(t *testing.T) {
+	ctx := context.Background()
+	src := &mockBackTracker{}
+	state := &mockState{}
+
+	result := New(src, state, Context(ctx))
+
+	assert.Same(t, src, result.Scanner)
+	assert.Equal(t, ctx, result.ctx)
+}
+
 func TestLexerNextInternalBase(t *testing.T) {
 	bt := &mockBackTracker{}
 	state := &mockState{}
@@ -59,6 +85,7 @@ func TestLexerNextInternalBase(t *testing.T) {
 		State:   state,
 		toks:    &list.List{},
 	}
+	bt.On("Err").Return(nil)
 	bt.On("SetMax", TrackAll).Once()
 	bt.On("BackTrack").Times(2)
 	bt.On("Accept", 0).Once()
@@ -89,6 +116,7 @@ func TestLexerNextInternalUnrecognized(t *testing.T) {
 		State:   state,
 		toks:    &list.List{},
 	}
+	bt.On("Err").Return(nil)
 	bt.On("SetMax", TrackAll).Once()
 	bt.On("BackTrack").Times(4)
 	bt.On("Accept", 0).Once()
@@ -113,6 +141,64 @@ func TestLexerNextInternalUnrecognized(t *testing.T) {
 	state.AssertExpectations(t)
 }
 
+func TestLexerNextInternalStopped(t *testing.T) {
+	bt := &mockBackTracker{}
+	state := &mockState{}
+	obj := &Lexer{
+		Scanner: bt,
+		State:   state,
+		toks:    &list.List{},
+	}
+	bt.On("Err").Return(nil).Once()
+	bt.On("Err").Return(ErrBackTrackLimit).Once()
+	bt.On("SetMax", TrackAll).Once()
+	bt.On("BackTrack").Once()
+	bt.On("Accept", 0).Once()
+	rec1 := &mockRecognizer{}
+	rec1.On("Recognize", obj).Return(false)
+	rec2 := &mockRecognizer{}
+	cls := &mockClassifier{}
+	cls.On("Classify", obj).Return([]Recognizer{rec1, rec2})
+	state.On("Classifier").Return(cls)
+
+	obj.next()
+
+	assert.Same(t, ErrBackTrackLimit, obj.err)
+	bt.AssertExpectations(t)
+	rec1.AssertExpectations(t)
+	rec2.AssertExpectations(t)
+	cls.AssertExpectations(t)
+	state.AssertExpectations(t)
+}
+
+func TestLexerNextInternalStoppedUnrecognized(t *testing.T) {
+	bt := &mockBackTracker{}
+	state := &mockState{}
+	obj := &Lexer{
+		Scanner: bt,
+		State:   state,
+		toks:    &list.List{},
+	}
+	bt.On("Err").Return(nil).Once()
+	bt.On("Err").Return(ErrBackTrackLimit).Once()
+	bt.On("SetMax", TrackAll).Once()
+	bt.On("BackTrack").Once()
+	bt.On("Accept", 0).Once()
+	rec1 := &mockRecognizer{}
+	rec1.On("Recognize", obj).Return(false)
+	cls := &mockClassifier{}
+	cls.On("Classify", obj).Return([]Recognizer{rec1})
+	state.On("Classifier").Return(cls)
+
+	obj.next()
+
+	assert.Same(t, ErrBackTrackLimit, obj.err)
+	bt.AssertExpectations(t)
+	rec1.AssertExpectations(t)
+	cls.AssertExpectations(t)
+	state.AssertExpectations(t)
+}
+
 func TestLexerNextInternalUnclassified(t *testing.T) {
 	bt := &mockBackTracker{}
 	state := &mockState{}
@@ -121,6 +207,7 @@ func TestLexerNextInternalUnclassified(t *testing.T) {
 		State:   state,
 		toks:    &list.List{},
 	}
+	bt.On("Err").Return(nil)
 	bt.On("SetMax", TrackAll).Once()
 	bt.On("BackTrack").Once()
 	bt.On("Accept", 0).Once()
@@ -164,6 +251,7 @@ func TestLexerNextLex(t *testing.T) {
 		State:   state,
 		toks:    &list.List{},
 	}
+	bt.On("Err").Return(nil)
 	bt.On("More").Return(true)
 	bt.On("SetMax", TrackAll).Once()
 	bt.On("BackTrack").Once()
@@ -192,6 +280,7 @@ func TestLexerNextClosed(t *testing.T) {
 		State:   state,
 		toks:    &list.List{},
 	}
+	bt.On("Err").Return(nil)
 	bt.On("More").Return(false)
 
 	result := obj.Next()
@@ -202,6 +291,195 @@ func TestLexerNextClosed(t *testing.T) {
 	state.AssertExpectations(t)
 }
 
+func TestLexerNextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	bt := &mockBackTracker{}
+	state := &mockState{}
+	obj := &Lexer{
+		Scanner: bt,
+		State:   state,
+		toks:    &list.List{},
+		ctx:     ctx,
+	}
+	loc := &mockLocation{}
+	bt.On("Err").Return(nil)
+	bt.On("Last").Return(scanner.Char{Rune: 'a', Loc: loc})
+
+	result := obj.Next()
+
+	assert.Nil(t, result)
+	assert.True(t, errors.Is(obj.err, context.Canceled))
+	assert.Same(t, loc, scanner.LocationOf(obj.err))
+	bt.AssertExpectations(t)
+	state.AssertExpectations(t)
+}
+
+func TestLexerNextCancelledBasic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	bt := &mockBackTracker{}
+	state := &mockState{}
+	obj := &Lexer{
+		Scanner: basicBackTracker{bt},
+		State:   state,
+		toks:    &list.List{},
+		ctx:     ctx,
+	}
+
+	result := obj.Next()
+
+	assert.Nil(t, result)
+	assert.Same(t, context.Canceled, obj.err)
+	bt.AssertExpectations(t)
+	state.AssertExpectations(t)
+}
+
+func TestLexerNextStopped(t *testing.T) {
+	bt := &mockBackTracker{}
+	state := &mockState{}
+	obj := &Lexer{
+		Scanner: bt,
+		State:   state,
+		toks:    &list.List{},
+		err:     assert.AnError,
+	}
+
+	result := obj.Next()
+
+	assert.Nil(t, result)
+	assert.Same(t, assert.AnError, obj.err)
+	bt.AssertExpectations(t)
+	state.AssertExpectations(t)
+}
+
+func TestLexerNextStoppedQueued(t *testing.T) {
+	tok := &Token{}
+	bt := &mockBackTracker{}
+	state := &mockState{}
+	obj := &Lexer{
+		Scanner: bt,
+		State:   state,
+		toks:    &list.List{},
+		err:     assert.AnError,
+	}
+	obj.toks.PushBack(tok)
+
+	result := obj.Next()
+
+	assert.Same(t, tok, result)
+	assert.Equal(t, 0, obj.toks.Len())
+	bt.AssertExpectations(t)
+	state.AssertExpectations(t)
+}
+
+func TestLexerNextPushFailedQueued(t *testing.T) {
+	tok := &Token{}
+	bt := &mockBackTracker{}
+	state := &mockState{}
+	obj := &Lexer{
+		Scanner: bt,
+		State:   state,
+		toks:    &list.List{},
+	}
+	bt.On("Err").Return(nil)
+	bt.On("More").Return(true).Once()
+	bt.On("SetMax", TrackAll).Once()
+	bt.On("BackTrack").Once()
+	bt.On("Accept", 0).Once()
+	cls := &mockClassifier{}
+	cls.On("Classify", obj).Return([]Recognizer{}).Once()
+	cls.On("Error", obj).Run(func(args mock.Arguments) {
+		obj.toks.PushBack(tok)
+		obj.err = assert.AnError
+	}).Once()
+	state.On("Classifier").Return(cls)
+
+	result := obj.Next()
+
+	assert.Same(t, tok, result)
+	assert.Nil(t, obj.Next())
+	bt.AssertExpectations(t)
+	state.AssertExpectations(t)
+	cls.AssertExpectations(t)
+}
+
+func TestLexerNextPushFailed(t *testing.T) {
+	bt := &mockBackTracker{}
+	state := &mockState{}
+	obj := &Lexer{
+		Scanner: bt,
+		State:   state,
+		toks:    &list.List{},
+	}
+	bt.On("Err").Return(nil)
+	bt.On("More").Return(true)
+	bt.On("SetMax", TrackAll).Once()
+	bt.On("BackTrack").Once()
+	bt.On("Accept", 0).Once()
+	cls := &mockClassifier{}
+	cls.On("Classify", obj).Return([]Recognizer{}).Once()
+	cls.On("Error", obj).Run(func(args mock.Arguments) {
+		obj.err = assert.AnError
+	}).Once()
+	state.On("Classifier").Return(cls)
+
+	result := obj.Next()
+
+	assert.Nil(t, result)
+	bt.AssertExpectations(t)
+	state.AssertExpectations(t)
+	cls.AssertExpectations(t)
+}
+
+func TestLexerErr(t *testing.T) {
+	obj := &Lexer{
+		err: assert.AnError,
+	}
+
+	result := obj.Err()
+
+	assert.Same(t, assert.AnError, result)
+}
+
+func TestLexerPushTooLong(t *testing.T) {
+	loc := &mockLocation{}
+	tok := &Token{Loc: loc}
+	bt := &mockBackTracker{}
+	bt.On("Pos").Return(4)
+	obj := &Lexer{
+		Scanner: bt,
+		toks:    &list.List{},
+		maxTok:  4,
+	}
+
+	result := obj.Push(tok)
+
+	assert.False(t, result)
+	assert.Equal(t, 0, obj.toks.Len())
+	assert.True(t, errors.Is(obj.err, ErrTokenTooLong))
+	assert.Same(t, loc, scanner.LocationOf(obj.err))
+	bt.AssertExpectations(t)
+}
+
+func TestLexerPushMaxLength(t *testing.T) {
+	tok := &Token{}
+	bt := &mockBackTracker{}
+	bt.On("Pos").Return(3)
+	obj := &Lexer{
+		Scanner: bt,
+		toks:    &list.List{},
+		maxTok:  4,
+	}
+
+	result := obj.Push(tok)
+
+	assert.True(t, result)
+	assert.Equal(t, 1, obj.toks.Len())
+	assert.Nil(t, obj.err)
+	bt.AssertExpectations(t)
+}
+
 func TestLexerPush(t *testing.T) {
 	tok := &Token{}
 	obj := &Lexer{
@@ -214,3 +492,84 @@ func TestLexerPush(t *testing.T) {
 	assert.Equal(t, 1, obj.toks.Len())
 	assert.Same(t, tok, obj.toks.Front().Value)
 }
+
+type wordClassifier struct{}
+
+func (c *wordClassifier) Classify(l *Lexer) []Recognizer {
+	return []Recognizer{c}
+}
+
+func (c *wordClassifier) Error(l *Lexer) {}
+
+func (c *wordClassifier) Recognize(l *Lexer) bool {
+	ch, err := l.Scanner.Next()
+	if err != nil || ch.Rune == scanner.EOF || unicode.IsSpace(ch.Rune) {
+		return true
+	}
+
+	for {
+		ch, err = l.Scanner.(scanner.IPeekScanner).Peek(0)
+		if err != nil || !unicode.IsLetter(ch.Rune) {
+			break
+		}
+		if _, err = l.Scanner.Next(); err != nil {
+			return false
+		}
+	}
+
+	bt := l.Scanner.(ITextBackTracker)
+	loc, _ := bt.Location()
+	return l.Push(&Token{Type: "word", Loc: loc, Text: bt.Text()})
+}
+
+func TestLexerInteractiveResume(t *testing.T) {
+	src := scanner.NewInteractiveScanner(scanner.FileLocation{
+		File: "<stdin>",
+		B:    scanner.FilePos{L: 1, C: 1},
+		E:    scanner.FilePos{L: 1, C: 1},
+	}, nil)
+	obj := New(src, &BaseState{Cls: &wordClassifier{}})
+	src.Feed("foo bar\n")
+
+	for _, word := range []string{"foo", "bar"} {
+		tok := obj.Next()
+		require.NotNil(t, tok)
+		assert.Equal(t, word, tok.Text)
+	}
+	assert.Nil(t, obj.Next())
+	assert.Nil(t, obj.Next())
+
+	src.Feed("baz\n")
+
+	tok := obj.Next()
+	require.NotNil(t, tok)
+	assert.Equal(t, "baz", tok.Text)
+	assert.Equal(t, scanner.FileLocation{
+		File: "<stdin>",
+		B:    scanner.FilePos{L: 2, C: 1, O: 8, R: 8},
+		E:    scanner.FilePos{L: 2, C: 4, O: 11, R: 11},
+	}, tok.Loc)
+	assert.Nil(t, obj.Next())
+	assert.NoError(t, obj.Err())
+}
+
+func TestLexerMaxBackTrack(t *testing.T) {
+	src := scanner.NewStringScanner("ab abcdefgh ij", scanner.FileLocation{
+		File: "file",
+		B:    scanner.FilePos{L: 1, C: 1},
+		E:    scanner.FilePos{L: 1, C: 1},
+	})
+	obj := New(src, &BaseState{Cls: &wordClassifier{}}, MaxBackTrack(4))
+
+	tok := obj.Next()
+	require.NotNil(t, tok)
+	assert.Equal(t, "ab", tok.Text)
+	assert.Nil(t, obj.Next())
+	assert.Nil(t, obj.Next())
+	assert.True(t, errors.Is(obj.Err(), ErrBackTrackLimit))
+	assert.Equal(t, scanner.FileLocation{
+		File: "file",
+		B:    scanner.FilePos{L: 1, C: 8, O: 7, R: 7},
+		E:    scanner.FilePos{L: 1, C: 9, O: 8, R: 8},
+	}, scanner.LocationOf(obj.Err()))
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lexer
+
+import (
+	"context"
+)
+
+// Option is an option that may be passed to the New function.
+type Option interface {
+	// lexerApply applies the option to the Lexer.
+	lexerApply(l *Lexer)
+}
+
+// MaxTokenLength is a lexer option that specifies the maximum number
+// of characters a recognizer may consume to produce a token.  If a
+// recognizer pushes a token after consuming more characters than
+// this, the push fails and the lexer stops, reporting ErrTokenTooLong
+// from its Err method.  The default is no limit.
+type MaxTokenLength int
+
+// lexerApply applies the option to the Lexer.
+func (o MaxTokenLength) lexerApply(l *Lexer) {
+	l.maxTok = int(o)
+}
+
+// MaxBackTrack is a lexer option that specifies the maximum number of
+// characters the lexer's backtracker may save between accepts; see
+// the SetLimit method of ILimitBackTracker.  Once the limit is
+// reached, the lexer stops, reporting ErrBackTrackLimit from its Err
+// method.  The option is ignored if the backtracker is not an
+// ILimitBackTracker.  The default is no limit.
+type MaxBackTrack int
+
+// lexerApply applies the option to the Lexer.
+func (o MaxBackTrack) lexerApply(l *Lexer) {
+	if lbt, ok := l.Scanner.(ILimitBackTracker); ok {
+		lbt.SetLimit(int(o))
+	}
+}
+
+// asyncOptions is a set of options for NewAsyncLexer.
+type asyncOptions struct {
+	ctx context.Context // The context to monitor
+}
+
+// AsyncOption is an option that may be passed to the NewAsyncLexer
+// function.
+type AsyncOption interface {
+	// asyncApply applies the option to asyncOptions.
+	asyncApply(o *asyncOptions)
+}
+
+// ContextOption is the type that stores the context that the lexer
+// should monitor for cancellation.
+type ContextOption struct {
+	ctx context.Context // The context to monitor
+}
+
+// lexerApply applies the option to the Lexer.
+func (co ContextOption) lexerApply(l *Lexer) {
+	l.ctx = co.ctx
+}
+
+// asyncApply applies the option to asyncOptions.
+func (co ContextOption) asyncApply(o *asyncOptions) {
+	o.ctx = co.ctx
+}
+
+// Context is an option that may be passed to either New or
+// NewAsyncLexer.  It is used to specify a context; once the context
+// is cancelled, the lexer stops returning tokens, and the goroutine
+// started by NewAsyncLexer exits.  Note that this does not affect the
+// scanner the lexer draws characters from; to stop a scanner that is
+// blocked reading its source, pass the scanner.Context option to the
+// scanner as well.
+func Context(ctx context.Context) ContextOption {
+	return ContextOption{ctx: ctx}
+}
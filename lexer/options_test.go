@@ -0,0 +1,96 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lexer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxTokenLengthImplementsOption(t *testing.T) {
+	assert.Implements(t, (*Option)(nil), MaxTokenLength(0))
+}
+
+func TestMaxTokenLengthLexerApply(t *testing.T) {
+	l := &Lexer{}
+	obj := MaxTokenLength(42)
+
+	obj.lexerApply(l)
+
+	assert.Equal(t, 42, l.maxTok)
+}
+
+func TestMaxBackTrackImplementsOption(t *testing.T) {
+	assert.Implements(t, (*Option)(nil), MaxBackTrack(0))
+}
+
+func TestMaxBackTrackLexerApply(t *testing.T) {
+	bt := &mockBackTracker{}
+	bt.On("SetLimit", 42)
+	l := &Lexer{Scanner: bt}
+	obj := MaxBackTrack(42)
+
+	obj.lexerApply(l)
+
+	bt.AssertExpectations(t)
+}
+
+func TestMaxBackTrackLexerApplyUnsupported(t *testing.T) {
+	bt := &mockBackTracker{}
+	l := &Lexer{Scanner: basicBackTracker{bt}}
+	obj := MaxBackTrack(42)
+
+	obj.lexerApply(l)
+
+	bt.AssertExpectations(t)
+}
+
+func TestContextOptionImplementsOption(t *testing.T) {
+	assert.Implements(t, (*Option)(nil), ContextOption{})
+}
+
+func TestContextOptionImplementsAsyncOption(t *testing.T) {
+	assert.Implements(t, (*AsyncOption)(nil), ContextOption{})
+}
+
+func TestContextOptionLexerApply(t *testing.T) {
+	ctx := context.Background()
+	l := &Lexer{}
+	obj := ContextOption{ctx: ctx}
+
+	obj.lexerApply(l)
+
+	assert.Equal(t, ctx, l.ctx)
+}
+
+func TestContextOptionAsyncApply(t *testing.T) {
+	ctx := context.Background()
+	o := &asyncOptions{}
+	obj := ContextOption{ctx: ctx}
+
+	obj.asyncApply(o)
+
+	assert.Equal(t, ctx, o.ctx)
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+
+	result := Context(ctx)
+
+	assert.Equal(t, ContextOption{ctx: ctx}, result)
+}
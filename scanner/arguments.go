@@ -16,14 +16,25 @@ package scanner
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 )
 
 // ArgPos specifies a particular character location within an argument
 // list.  It is a component of the ArgLocation type.
 type ArgPos struct {
-	I int // The index of the argument in its list
-	C int // The index of the character within the argument
+	I int `json:"arg"`  // The index of the argument in its list
+	C int `json:"char"` // The index of the character within the argument
+}
+
+// cmp compares the position with another position by argument index
+// and character index.
+func (p ArgPos) cmp(o ArgPos) int {
+	if p.I != o.I {
+		return compareInts(p.I, o.I)
+	}
+
+	return compareInts(p.C, o.C)
 }
 
 // ArgLocation is an implementation of Location that identifies the
@@ -31,8 +42,8 @@ type ArgPos struct {
 // line arguments.  It represents a full range, but tab stops and
 // newlines are not treated specially.
 type ArgLocation struct {
-	B ArgPos // Beginning of the range
-	E ArgPos // End of the range
+	B ArgPos `json:"begin"` // Beginning of the range
+	E ArgPos `json:"end"`   // End of the range
 }
 
 // String constructs a string representation of the location.
@@ -100,6 +111,98 @@ func (l ArgLocation) Incr(c rune, tabstop int) Location {
 	return l
 }
 
+// Compare compares this location with another location.  It returns
+// a negative number if this location sorts before the other location,
+// a positive number if it sorts after, and 0 if the locations are the
+// same.
+func (l ArgLocation) Compare(other Location) (int, error) {
+	o, ok := other.(ArgLocation)
+	switch {
+	case !ok:
+		return 0, ErrSplitLocation
+	case l.B.cmp(o.B) != 0:
+		return l.B.cmp(o.B), nil
+	}
+
+	return l.E.cmp(o.E), nil
+}
+
+// Before returns true if this location ends at or before the
+// beginning of another location.
+func (l ArgLocation) Before(other Location) bool {
+	o, ok := other.(ArgLocation)
+	return ok && l.E.cmp(o.B) <= 0
+}
+
+// Contains returns true if another location lies entirely within this
+// location.
+func (l ArgLocation) Contains(other Location) bool {
+	o, ok := other.(ArgLocation)
+	return ok && l.B.cmp(o.B) <= 0 && o.E.cmp(l.E) <= 0
+}
+
+// Overlaps returns true if this location and another location have
+// any characters in common.  An empty location overlaps another
+// location if it lies within it.
+func (l ArgLocation) Overlaps(other Location) bool {
+	o, ok := other.(ArgLocation)
+	return ok && (l.B.cmp(o.E) < 0 && o.B.cmp(l.E) < 0 || l.Contains(o) || o.Contains(l))
+}
+
+// Union creates a new Location that ranges from the earlier of the
+// beginnings of this location and another location to the later of
+// their endings.
+func (l ArgLocation) Union(other Location) (Location, error) {
+	// Verify that other's compatible
+	o, ok := other.(ArgLocation)
+	if !ok {
+		return nil, ErrSplitLocation
+	}
+
+	if o.B.cmp(l.B) < 0 {
+		l.B = o.B
+	}
+	if o.E.cmp(l.E) > 0 {
+		l.E = o.E
+	}
+
+	return l, nil
+}
+
+// MarshalJSON encodes the location as JSON.  The encoding includes a
+// "type" field, which allows UnmarshalLocation to decode it.
+func (l ArgLocation) MarshalJSON() ([]byte, error) {
+	type plain ArgLocation
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		plain
+	}{
+		Type:  ArgLocationType,
+		plain: plain(l),
+	})
+}
+
+// UnmarshalJSON decodes the location from JSON.  If the encoding
+// includes a "type" field, it must designate an ArgLocation.
+func (l *ArgLocation) UnmarshalJSON(data []byte) error {
+	type plain ArgLocation
+	tmp := struct {
+		Type string `json:"type"`
+		*plain
+	}{
+		plain: (*plain)(l),
+	}
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+
+	if tmp.Type != "" && tmp.Type != ArgLocationType {
+		return ErrLocationType
+	}
+
+	return nil
+}
+
 // NewArgumentScanner constructs and returns a Scanner implementation
 // that returns characters drawn from a provided list of argument
 // strings.  This is intended for use with arguments taken from the
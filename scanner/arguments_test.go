@@ -15,6 +15,7 @@
 package scanner
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -25,6 +26,137 @@ func TestArgLocationImplementsLocation(t *testing.T) {
 	assert.Implements(t, (*Location)(nil), &ArgLocation{})
 }
 
+func TestArgPosCmp(t *testing.T) {
+	p := ArgPos{I: 2, C: 5}
+
+	assert.Equal(t, 0, p.cmp(ArgPos{I: 2, C: 5}))
+	assert.Equal(t, -1, p.cmp(ArgPos{I: 2, C: 6}))
+	assert.Equal(t, 1, p.cmp(ArgPos{I: 2, C: 4}))
+	assert.Equal(t, -1, p.cmp(ArgPos{I: 3, C: 1}))
+	assert.Equal(t, 1, p.cmp(ArgPos{I: 1, C: 9}))
+}
+
+func TestArgLocationImplementsOrderedLocation(t *testing.T) {
+	assert.Implements(t, (*OrderedLocation)(nil), ArgLocation{})
+}
+
+// argLoc is a helper for constructing ArgLocation values within a
+// single argument.
+func argLoc(i, b, e int) ArgLocation {
+	return ArgLocation{
+		B: ArgPos{I: i, C: b},
+		E: ArgPos{I: i, C: e},
+	}
+}
+
+func TestArgLocationCompare(t *testing.T) {
+	loc := argLoc(2, 3, 5)
+
+	for other, expected := range map[Location]int{
+		argLoc(2, 3, 5): 0,
+		argLoc(2, 3, 6): -1,
+		argLoc(2, 3, 4): 1,
+		argLoc(2, 4, 4): -1,
+		argLoc(1, 9, 9): 1,
+	} {
+		result, err := loc.Compare(other)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, result, "%s", other)
+	}
+}
+
+func TestArgLocationCompareIncompatible(t *testing.T) {
+	loc := argLoc(2, 3, 5)
+
+	result, err := loc.Compare(FileLocation{})
+
+	assert.Same(t, ErrSplitLocation, err)
+	assert.Equal(t, 0, result)
+}
+
+func TestArgLocationBefore(t *testing.T) {
+	loc := argLoc(2, 3, 5)
+
+	assert.True(t, loc.Before(argLoc(2, 5, 6)))
+	assert.True(t, loc.Before(argLoc(3, 1, 2)))
+	assert.False(t, loc.Before(argLoc(2, 4, 6)))
+	assert.False(t, loc.Before(FileLocation{}))
+}
+
+func TestArgLocationContains(t *testing.T) {
+	loc := argLoc(2, 3, 7)
+
+	assert.True(t, loc.Contains(argLoc(2, 3, 7)))
+	assert.True(t, loc.Contains(argLoc(2, 4, 5)))
+	assert.False(t, loc.Contains(argLoc(2, 2, 5)))
+	assert.False(t, loc.Contains(argLoc(2, 5, 8)))
+	assert.False(t, loc.Contains(FileLocation{}))
+}
+
+func TestArgLocationOverlaps(t *testing.T) {
+	loc := argLoc(2, 3, 7)
+
+	assert.True(t, loc.Overlaps(argLoc(2, 1, 4)))
+	assert.True(t, loc.Overlaps(argLoc(2, 6, 9)))
+	assert.True(t, loc.Overlaps(argLoc(2, 4, 4)))
+	assert.False(t, loc.Overlaps(argLoc(2, 1, 3)))
+	assert.False(t, loc.Overlaps(argLoc(2, 7, 9)))
+	assert.False(t, loc.Overlaps(FileLocation{}))
+}
+
+func TestArgLocationUnion(t *testing.T) {
+	loc := argLoc(2, 3, 7)
+
+	result, err := loc.Union(argLoc(3, 1, 2))
+	assert.NoError(t, err)
+	assert.Equal(t, ArgLocation{B: ArgPos{I: 2, C: 3}, E: ArgPos{I: 3, C: 2}}, result)
+	result, err = loc.Union(argLoc(2, 1, 4))
+	assert.NoError(t, err)
+	assert.Equal(t, argLoc(2, 1, 7), result)
+}
+
+func TestArgLocationUnionIncompatible(t *testing.T) {
+	loc := argLoc(2, 3, 7)
+
+	result, err := loc.Union(FileLocation{})
+
+	assert.Same(t, ErrSplitLocation, err)
+	assert.Nil(t, result)
+}
+
+func TestArgLocationMarshalJSON(t *testing.T) {
+	result, err := json.Marshal(argLoc(1, 2, 3))
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"arg","begin":{"arg":1,"char":2},"end":{"arg":1,"char":3}}`, string(result))
+}
+
+func TestArgLocationUnmarshalJSON(t *testing.T) {
+	loc := ArgLocation{}
+
+	err := json.Unmarshal([]byte(`{"begin":{"arg":1,"char":2},"end":{"arg":1,"char":3}}`), &loc)
+
+	assert.NoError(t, err)
+	assert.Equal(t, argLoc(1, 2, 3), loc)
+}
+
+func TestArgLocationUnmarshalJSONWrongType(t *testing.T) {
+	loc := ArgLocation{}
+
+	err := json.Unmarshal([]byte(`{"type":"file"}`), &loc)
+
+	assert.Same(t, ErrLocationType, err)
+}
+
+func TestArgLocationUnmarshalJSONBad(t *testing.T) {
+	loc := ArgLocation{}
+
+	err := json.Unmarshal([]byte(`{"begin":1}`), &loc)
+
+	assert.Error(t, err)
+}
+
 func TestArgLocationString0Columns(t *testing.T) {
 	loc := ArgLocation{
 		B: ArgPos{1, 3},
@@ -0,0 +1,245 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"context"
+	"sync"
+)
+
+// Defaults for the asynchronous scanner.
+const (
+	DefaultBatchSize  = 256 // Default number of characters per batch
+	DefaultBufferSize = 4   // Default number of batches to buffer
+)
+
+// asyncElem is a struct type containing a character and error
+// returned by the source scanner.
+type asyncElem struct {
+	ch  Char  // The character returned
+	err error // The error returned
+}
+
+// AsyncScanner is an implementation of Scanner that reads characters
+// from another scanner in a separate goroutine.  This allows the
+// source scanner to decode characters from a slow source, such as a
+// pipe or a file on a network file system, while the consumer
+// processes earlier characters.  Characters are passed to the
+// consumer in batches.  Whenever there is room for another batch, the
+// characters read so far are handed over, so the consumer is never
+// kept waiting for characters that have already been read; otherwise,
+// characters accumulate until the batch is full, or until the source
+// scanner returns an error or EOF.  Errors are returned along with the
+// same character they were returned with by the source scanner, so a
+// read error deferred by the source until after the characters
+// preceding it is reported at the same position.
+type AsyncScanner struct {
+	batches <-chan []asyncElem // Batches of characters from the goroutine
+	done    chan struct{}      // Closed to stop the goroutine
+	once    sync.Once          // Guards closing done
+	ctx     context.Context    // Context to monitor for cancellation
+	batch   []asyncElem        // The current batch of characters
+	last    Char               // The last character returned
+	eof     bool               // Whether EOF has been returned
+}
+
+// NewAsyncScanner wraps another scanner and starts goroutines that
+// read characters from it and collect them into batches.  The
+// BatchSize and BufferSize options control the number of characters
+// per batch and the number of batches that may be waiting for the
+// consumer; once the buffer is full, the goroutines wait for the
+// consumer to catch up.  If a context is passed with the Context
+// option, the goroutines exit once the context is cancelled, and the
+// scanner returns EOF with the context's error.  The goroutines also
+// exit once the source scanner returns EOF, or when Close is called;
+// a consumer that stops reading before EOF should call Close to
+// release the goroutines.
+func NewAsyncScanner(src Scanner, options ...AsyncOption) *AsyncScanner {
+	// Process the options
+	opts := &asyncOptions{
+		batch: DefaultBatchSize,
+		buf:   DefaultBufferSize,
+	}
+	for _, opt := range options {
+		opt.asyncApply(opts)
+	}
+	if opts.batch < 1 {
+		opts.batch = 1
+	}
+	if opts.buf < 0 {
+		opts.buf = 0
+	}
+
+	batches := make(chan []asyncElem, opts.buf)
+	s := &AsyncScanner{
+		batches: batches,
+		done:    make(chan struct{}),
+		ctx:     opts.ctx,
+	}
+
+	// Run the source scanner in the background
+	go s.run(src, batches, opts.batch)
+
+	return s
+}
+
+// cancelled is a helper that returns the channel that is closed when
+// the context is cancelled.  If there is no context, it returns a nil
+// channel, which is never ready.
+func (s *AsyncScanner) cancelled() <-chan struct{} {
+	if s.ctx != nil {
+		return s.ctx.Done()
+	}
+
+	return nil
+}
+
+// read reads characters from the source scanner and sends them to
+// run, one at a time.  It runs in its own goroutine, so that run can
+// hand characters over to the consumer while the source scanner is
+// blocked reading its source.
+func (s *AsyncScanner) read(src Scanner, elems chan<- asyncElem) {
+	cancel := s.cancelled()
+	for {
+		ch, err := src.Next()
+		select {
+		case elems <- asyncElem{ch: ch, err: err}:
+		case <-s.done:
+			return
+		case <-cancel:
+			return
+		}
+
+		if ch.Rune == EOF {
+			return
+		}
+	}
+}
+
+// run collects the characters read from the source scanner and sends
+// them to the consumer in batches.  It runs in its own goroutine.
+// The characters collected so far are sent whenever there is room for
+// another batch; otherwise, characters are collected until the batch
+// is full, or until the source scanner returns an error or EOF.
+func (s *AsyncScanner) run(src Scanner, batches chan<- []asyncElem, size int) {
+	defer close(batches)
+
+	elems := make(chan asyncElem)
+	go s.read(src, elems)
+
+	cancel := s.cancelled()
+	batch := make([]asyncElem, 0, size)
+	flush := false
+	for {
+		// Only send a batch if there's something in it, and
+		// only read if the batch isn't ready to be handed over
+		var out chan<- []asyncElem
+		if len(batch) > 0 {
+			out = batches
+		}
+		in := elems
+		if flush || len(batch) >= size {
+			in = nil
+		}
+
+		select {
+		case elem := <-in:
+			batch = append(batch, elem)
+			flush = elem.err != nil || elem.ch.Rune == EOF
+
+		case out <- batch:
+			if batch[len(batch)-1].ch.Rune == EOF {
+				return
+			}
+			batch = make([]asyncElem, 0, size)
+			flush = false
+
+		case <-s.done:
+			return
+		case <-cancel:
+			return
+		}
+	}
+}
+
+// stop is a helper that sets the scanner to return EOF following the
+// last character returned.
+func (s *AsyncScanner) stop() {
+	s.eof = true
+	s.batch = nil
+	if s.last.Rune != EOF {
+		var loc Location
+		if s.last.Loc != nil {
+			loc = s.last.Loc.Incr(EOF, DefaultTabStop)
+		}
+		s.last = Char{Rune: EOF, Loc: loc}
+	}
+}
+
+// Next returns the next character from the stream as a Char, which
+// will include the character's location.  If an error was
+// encountered, that will also be returned.
+func (s *AsyncScanner) Next() (Char, error) {
+	// Get the next batch if needed
+	if len(s.batch) == 0 {
+		if s.eof {
+			return s.last, nil
+		}
+
+		var batch []asyncElem
+		ok := false
+		select {
+		case batch, ok = <-s.batches:
+		case <-s.cancelled():
+		}
+
+		// Did the goroutine stop early?
+		if !ok {
+			s.stop()
+			var err error
+			if s.ctx != nil && s.ctx.Err() != nil {
+				err = LocationError(s.last.Loc, s.ctx.Err())
+			}
+			return s.last, err
+		}
+
+		s.batch = batch
+	}
+
+	// Return the next character from the batch
+	elem := s.batch[0]
+	s.batch = s.batch[1:]
+	s.last = elem.ch
+	if elem.ch.Rune == EOF {
+		s.stop()
+	}
+
+	return elem.ch, elem.err
+}
+
+// Close stops the goroutines reading from the source scanner and
+// discards any characters they have read that have not been returned
+// by Next.  After Close is called, Next returns EOF.  Note that if the
+// source scanner is blocked reading its source, the goroutine reading
+// from it exits only once the read completes; use the Context option on the source
+// scanner to interrupt the read.  Close always returns nil.
+func (s *AsyncScanner) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	s.stop()
+
+	return nil
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// waitClosed is a helper that waits for the goroutine started by
+// NewAsyncScanner to exit.
+func waitClosed(t *testing.T, s *AsyncScanner) {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-s.batches:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("goroutine did not exit")
+		}
+	}
+}
+
+func TestAsyncScannerImplementsScanner(t *testing.T) {
+	assert.Implements(t, (*Scanner)(nil), &AsyncScanner{})
+}
+
+func TestNewAsyncScannerBase(t *testing.T) {
+	src := NewListScanner([]Char{
+		{Rune: 'a', Loc: fileLoc("file", 1, 1, 2)},
+		{Rune: 'b', Loc: fileLoc("file", 1, 2, 3)},
+		{Rune: 'c', Loc: fileLoc("file", 1, 3, 4)},
+		{Rune: EOF, Loc: fileLoc("file", 1, 4, 4)},
+	}, nil)
+
+	obj := NewAsyncScanner(src, BatchSize(2), BufferSize(1))
+
+	assert.Equal(t, 1, cap(obj.batches))
+	result, err := scanAll(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", result)
+	ch, err := obj.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: EOF, Loc: fileLoc("file", 1, 4, 4)}, ch)
+	waitClosed(t, obj)
+}
+
+func TestNewAsyncScannerClamped(t *testing.T) {
+	src := NewListScanner([]Char{
+		{Rune: 'a'},
+		{Rune: EOF},
+	}, nil)
+
+	obj := NewAsyncScanner(src, BatchSize(0), BufferSize(-1))
+
+	assert.Equal(t, 0, cap(obj.batches))
+	result, err := scanAll(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", result)
+	waitClosed(t, obj)
+}
+
+func TestAsyncScannerDeferredError(t *testing.T) {
+	src := NewListScanner([]Char{
+		{Rune: 'a', Loc: fileLoc("file", 1, 1, 2)},
+		{Rune: 'b', Loc: fileLoc("file", 1, 2, 3)},
+		{Rune: EOF, Loc: fileLoc("file", 1, 3, 3)},
+	}, assert.AnError)
+	obj := NewAsyncScanner(src)
+
+	for _, r := range "ab" {
+		ch, err := obj.Next()
+		require.NoError(t, err)
+		assert.Equal(t, r, ch.Rune)
+	}
+	ch, err := obj.Next()
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, Char{Rune: EOF, Loc: fileLoc("file", 1, 3, 3)}, ch)
+	ch, err = obj.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, EOF, ch.Rune)
+	waitClosed(t, obj)
+}
+
+func TestAsyncScannerErrorFlushesBatch(t *testing.T) {
+	block := make(chan struct{})
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 'a'}, nil).Once()
+	src.On("Next").Return(Char{Rune: 'b'}, assert.AnError).Once()
+	src.On("Next").Return(Char{Rune: EOF}, nil).Run(func(args mock.Arguments) {
+		<-block
+	})
+	obj := NewAsyncScanner(src)
+
+	ch, err := obj.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, 'a', ch.Rune)
+	ch, err = obj.Next()
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, 'b', ch.Rune)
+
+	assert.NoError(t, obj.Close())
+	close(block)
+	waitClosed(t, obj)
+}
+
+func TestAsyncScannerClose(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 'a', Loc: fileLoc("file", 1, 1, 2)}, nil)
+	obj := NewAsyncScanner(src, BatchSize(1))
+	ch, err := obj.Next()
+	require.NoError(t, err)
+	require.Equal(t, 'a', ch.Rune)
+
+	err = obj.Close()
+
+	assert.NoError(t, err)
+	ch, err = obj.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: EOF, Loc: FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 2},
+		E:    FilePos{L: 1, C: 2},
+	}}, ch)
+	assert.NoError(t, obj.Close())
+	waitClosed(t, obj)
+}
+
+func TestAsyncScannerContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 'a', Loc: fileLoc("file", 1, 1, 2)}, nil)
+	obj := NewAsyncScanner(src, BatchSize(1), Context(ctx))
+	ch, err := obj.Next()
+	require.NoError(t, err)
+	require.Equal(t, 'a', ch.Rune)
+
+	cancel()
+
+	for i := 0; ch.Rune != EOF; i++ {
+		require.Less(t, i, DefaultBufferSize+2)
+		ch, err = obj.Next()
+	}
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.NotNil(t, LocationOf(err))
+	ch, err = obj.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, EOF, ch.Rune)
+	waitClosed(t, obj)
+}
+
+func TestAsyncScannerPartialBatch(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	go func() {
+		_, _ = w.Write([]byte("ab\n"))
+	}()
+	obj := NewAsyncScanner(NewFileScanner(r, fileLoc("file", 1, 1, 1)))
+	defer obj.Close()
+	chars := make(chan rune)
+	go func() {
+		for i := 0; i < 3; i++ {
+			ch, _ := obj.Next()
+			chars <- ch.Rune
+		}
+	}()
+
+	for _, r := range "ab\n" {
+		select {
+		case ch := <-chars:
+			assert.Equal(t, r, ch)
+		case <-time.After(time.Second):
+			t.Fatalf("character %q not returned", r)
+		}
+	}
+}
@@ -24,6 +24,7 @@ const (
 	EOF      rune = unicode.MaxRune + iota + 1 // Signals end-of-file
 	errRune                                    // Signals an error
 	sentinel                                   // Signals saved is unset
+	skipRune                                   // Signals input was skipped
 )
 
 // Char represents a character retrieved from the source input stream.
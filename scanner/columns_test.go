@@ -0,0 +1,83 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColumnUnitImplementsFileOption(t *testing.T) {
+	assert.Implements(t, (*FileOption)(nil), ColumnRunes)
+}
+
+func TestColumnUnitFileApply(t *testing.T) {
+	s := &FileScanner{}
+	obj := ColumnUTF16
+
+	obj.fileApply(s)
+
+	assert.Equal(t, ColumnUTF16, s.cu)
+}
+
+func TestColumnUnitImplementsMemoryOption(t *testing.T) {
+	assert.Implements(t, (*MemoryOption)(nil), ColumnRunes)
+}
+
+func TestColumnUnitMemoryApply(t *testing.T) {
+	s := &MemoryScanner{}
+	obj := ColumnUTF16
+
+	obj.memoryApply(s)
+
+	assert.Equal(t, ColumnUTF16, s.cu)
+}
+
+func TestColumnUnitColumnsRunes(t *testing.T) {
+	assert.Equal(t, 1, ColumnRunes.Columns('a', Extent{Bytes: 1, Runes: 1}))
+	assert.Equal(t, 1, ColumnRunes.Columns('中', Extent{Bytes: 3, Runes: 1}))
+	assert.Equal(t, 1, ColumnRunes.Columns('\U0001f600', Extent{Bytes: 4, Runes: 1}))
+}
+
+func TestColumnUnitColumnsBytes(t *testing.T) {
+	assert.Equal(t, 1, ColumnBytes.Columns('a', Extent{Bytes: 1, Runes: 1}))
+	assert.Equal(t, 3, ColumnBytes.Columns('中', Extent{Bytes: 3, Runes: 1}))
+	assert.Equal(t, 2, ColumnBytes.Columns('ñ', Extent{Bytes: 2, Runes: 1}))
+}
+
+func TestColumnUnitColumnsUTF16(t *testing.T) {
+	assert.Equal(t, 1, ColumnUTF16.Columns('a', Extent{Bytes: 1, Runes: 1}))
+	assert.Equal(t, 1, ColumnUTF16.Columns('中', Extent{Bytes: 3, Runes: 1}))
+	assert.Equal(t, 2, ColumnUTF16.Columns('\U0001f600', Extent{Bytes: 4, Runes: 1}))
+}
+
+func TestColumnUnitColumnsWidth(t *testing.T) {
+	assert.Equal(t, 1, ColumnWidth.Columns('a', Extent{Bytes: 1, Runes: 1}))
+	assert.Equal(t, 2, ColumnWidth.Columns('中', Extent{Bytes: 3, Runes: 1}))
+	assert.Equal(t, 0, ColumnWidth.Columns('́', Extent{Bytes: 2, Runes: 1}))
+}
+
+func TestRuneWidth(t *testing.T) {
+	assert.Equal(t, 1, RuneWidth('a'))
+	assert.Equal(t, 1, RuneWidth('ñ'))
+	assert.Equal(t, 0, RuneWidth('́'))
+	assert.Equal(t, 0, RuneWidth('​'))
+	assert.Equal(t, 2, RuneWidth('中'))
+	assert.Equal(t, 2, RuneWidth('가'))
+	assert.Equal(t, 2, RuneWidth('Ａ'))
+	assert.Equal(t, 2, RuneWidth('\U0001f600'))
+	assert.Equal(t, 1, RuneWidth('☃'))
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"encoding/binary"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Byte order marks recognized by the DetectEncoding option.
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// Decoder is an interface for a character decoder.  A FileScanner
+// uses a Decoder to convert the bytes read from its source into
+// runes.  The scanner guarantees that DecodeRune will only be called
+// with a non-empty byte slice.
+type Decoder interface {
+	// FullRune reports whether the bytes in p begin with a full
+	// encoding of a character.  An invalid encoding is
+	// considered a full character, as it will be reported as an
+	// encoding error.
+	FullRune(p []byte) bool
+
+	// DecodeRune decodes the first character in p, returning the
+	// character and its width in bytes.  If the encoding is
+	// invalid, it must return a non-nil error along with the
+	// number of bytes to skip.
+	DecodeRune(p []byte) (r rune, width int, err error)
+}
+
+// utf8Decoder is a Decoder for the UTF-8 encoding.
+type utf8Decoder struct{}
+
+// FullRune reports whether the bytes in p begin with a full encoding
+// of a character.  An invalid encoding is considered a full
+// character, as it will be reported as an encoding error.
+func (d *utf8Decoder) FullRune(p []byte) bool {
+	return utf8.FullRune(p)
+}
+
+// DecodeRune decodes the first character in p, returning the
+// character and its width in bytes.  If the encoding is invalid, it
+// must return a non-nil error along with the number of bytes to skip.
+func (d *utf8Decoder) DecodeRune(p []byte) (rune, int, error) {
+	r, width := utf8.DecodeRune(p)
+	if r == utf8.RuneError && width <= 1 {
+		return r, 1, ErrBadEncoding
+	}
+
+	return r, width, nil
+}
+
+// UTF8 is a Decoder for the UTF-8 encoding.  This is the default
+// encoding used by FileScanner.
+var UTF8 Decoder = &utf8Decoder{}
+
+// utf16Decoder is a Decoder for the UTF-16 encoding.
+type utf16Decoder struct {
+	order binary.ByteOrder // The byte order of the encoding
+}
+
+// FullRune reports whether the bytes in p begin with a full encoding
+// of a character.  An invalid encoding is considered a full
+// character, as it will be reported as an encoding error.
+func (d *utf16Decoder) FullRune(p []byte) bool {
+	if len(p) < 2 {
+		return false
+	}
+
+	// A leading surrogate requires a trailing surrogate
+	r := rune(d.order.Uint16(p))
+	if utf16.IsSurrogate(r) && r < 0xdc00 {
+		return len(p) >= 4
+	}
+
+	return true
+}
+
+// DecodeRune decodes the first character in p, returning the
+// character and its width in bytes.  If the encoding is invalid, it
+// must return a non-nil error along with the number of bytes to skip.
+func (d *utf16Decoder) DecodeRune(p []byte) (rune, int, error) {
+	if len(p) < 2 {
+		return utf8.RuneError, len(p), ErrBadUTF16
+	}
+
+	// Handle the basic multilingual plane
+	r1 := rune(d.order.Uint16(p))
+	if !utf16.IsSurrogate(r1) {
+		return r1, 2, nil
+	}
+
+	// Decode the surrogate pair
+	if len(p) >= 4 {
+		if r := utf16.DecodeRune(r1, rune(d.order.Uint16(p[2:]))); r != utf8.RuneError {
+			return r, 4, nil
+		}
+	}
+
+	return utf8.RuneError, 2, ErrBadUTF16
+}
+
+// Decoders for the UTF-16 encodings.
+var (
+	UTF16LE Decoder = &utf16Decoder{order: binary.LittleEndian}
+	UTF16BE Decoder = &utf16Decoder{order: binary.BigEndian}
+)
+
+// latin1Decoder is a Decoder for the ISO 8859-1 encoding.
+type latin1Decoder struct{}
+
+// FullRune reports whether the bytes in p begin with a full encoding
+// of a character.  An invalid encoding is considered a full
+// character, as it will be reported as an encoding error.
+func (d *latin1Decoder) FullRune(p []byte) bool {
+	return len(p) >= 1
+}
+
+// DecodeRune decodes the first character in p, returning the
+// character and its width in bytes.  If the encoding is invalid, it
+// must return a non-nil error along with the number of bytes to skip.
+func (d *latin1Decoder) DecodeRune(p []byte) (rune, int, error) {
+	return rune(p[0]), 1, nil
+}
+
+// Latin1 is a Decoder for the ISO 8859-1 encoding, also known as
+// Latin-1.  Every byte is a valid character in this encoding.
+var Latin1 Decoder = &latin1Decoder{}
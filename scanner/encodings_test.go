@@ -0,0 +1,147 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockDecoder struct {
+	mock.Mock
+}
+
+func (m *mockDecoder) FullRune(p []byte) bool {
+	args := m.MethodCalled("FullRune", p)
+
+	return args.Bool(0)
+}
+
+func (m *mockDecoder) DecodeRune(p []byte) (rune, int, error) {
+	args := m.MethodCalled("DecodeRune", p)
+
+	return args.Get(0).(rune), args.Int(1), args.Error(2)
+}
+
+func TestUTF8ImplementsDecoder(t *testing.T) {
+	assert.Implements(t, (*Decoder)(nil), UTF8)
+}
+
+func TestUTF8FullRune(t *testing.T) {
+	assert.True(t, UTF8.FullRune([]byte{'a'}))
+	assert.True(t, UTF8.FullRune([]byte{195, 177}))
+	assert.False(t, UTF8.FullRune([]byte{195}))
+	assert.False(t, UTF8.FullRune([]byte{}))
+}
+
+func TestUTF8DecodeRuneBase(t *testing.T) {
+	r, width, err := UTF8.DecodeRune([]byte{195, 177, 'a'})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 'ñ', r)
+	assert.Equal(t, 2, width)
+}
+
+func TestUTF8DecodeRuneBad(t *testing.T) {
+	r, width, err := UTF8.DecodeRune([]byte{177, 'a'})
+
+	assert.Same(t, ErrBadEncoding, err)
+	assert.Equal(t, utf8.RuneError, r)
+	assert.Equal(t, 1, width)
+}
+
+func TestUTF16LEImplementsDecoder(t *testing.T) {
+	assert.Implements(t, (*Decoder)(nil), UTF16LE)
+}
+
+func TestUTF16LEFullRune(t *testing.T) {
+	assert.True(t, UTF16LE.FullRune([]byte{'a', 0}))
+	assert.True(t, UTF16LE.FullRune([]byte{0x3d, 0xd8, 0x00, 0xde}))
+	assert.True(t, UTF16LE.FullRune([]byte{0x00, 0xde}))
+	assert.False(t, UTF16LE.FullRune([]byte{0x3d, 0xd8, 0x00}))
+	assert.False(t, UTF16LE.FullRune([]byte{'a'}))
+}
+
+func TestUTF16LEDecodeRuneBase(t *testing.T) {
+	r, width, err := UTF16LE.DecodeRune([]byte{'a', 0, 'b', 0})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 'a', r)
+	assert.Equal(t, 2, width)
+}
+
+func TestUTF16LEDecodeRuneSurrogates(t *testing.T) {
+	r, width, err := UTF16LE.DecodeRune([]byte{0x3d, 0xd8, 0x00, 0xde})
+
+	assert.NoError(t, err)
+	assert.Equal(t, '\U0001f600', r)
+	assert.Equal(t, 4, width)
+}
+
+func TestUTF16LEDecodeRuneShort(t *testing.T) {
+	r, width, err := UTF16LE.DecodeRune([]byte{'a'})
+
+	assert.Same(t, ErrBadUTF16, err)
+	assert.Equal(t, utf8.RuneError, r)
+	assert.Equal(t, 1, width)
+}
+
+func TestUTF16LEDecodeRuneUnpaired(t *testing.T) {
+	r, width, err := UTF16LE.DecodeRune([]byte{0x3d, 0xd8, 'a', 0})
+
+	assert.Same(t, ErrBadUTF16, err)
+	assert.Equal(t, utf8.RuneError, r)
+	assert.Equal(t, 2, width)
+}
+
+func TestUTF16LEDecodeRuneTruncated(t *testing.T) {
+	r, width, err := UTF16LE.DecodeRune([]byte{0x3d, 0xd8})
+
+	assert.Same(t, ErrBadUTF16, err)
+	assert.Equal(t, utf8.RuneError, r)
+	assert.Equal(t, 2, width)
+}
+
+func TestUTF16BEImplementsDecoder(t *testing.T) {
+	assert.Implements(t, (*Decoder)(nil), UTF16BE)
+}
+
+func TestUTF16BEDecodeRune(t *testing.T) {
+	r, width, err := UTF16BE.DecodeRune([]byte{0xd8, 0x3d, 0xde, 0x00})
+
+	assert.NoError(t, err)
+	assert.Equal(t, '\U0001f600', r)
+	assert.Equal(t, 4, width)
+}
+
+func TestLatin1ImplementsDecoder(t *testing.T) {
+	assert.Implements(t, (*Decoder)(nil), Latin1)
+}
+
+func TestLatin1FullRune(t *testing.T) {
+	assert.True(t, Latin1.FullRune([]byte{0xf1}))
+	assert.False(t, Latin1.FullRune([]byte{}))
+}
+
+func TestLatin1DecodeRune(t *testing.T) {
+	r, width, err := Latin1.DecodeRune([]byte{0xf1, 'a'})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 'ñ', r)
+	assert.Equal(t, 1, width)
+}
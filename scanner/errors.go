@@ -17,12 +17,29 @@ package scanner
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 // Simple errors that may be generated within the package.
 var (
-	ErrSplitLocation = errors.New("Attempt to range file location through an incompatible location")
-	ErrBadEncoding   = errors.New("Invalid UTF-8 encoding")
+	ErrSplitLocation      = errors.New("Attempt to range file location through an incompatible location")
+	ErrBadEncoding        = errors.New("Invalid UTF-8 encoding")
+	ErrBadUTF16           = errors.New("Invalid UTF-16 encoding")
+	ErrSkipInvalid        = errors.New("Skip invalid input")
+	ErrInputTooLarge      = errors.New("Input exceeds maximum size")
+	ErrBadMark            = errors.New("Mark has been released")
+	ErrSeekLocation       = errors.New("Location is not available to seek to")
+	ErrUnreadRune         = errors.New("Invalid use of UnreadRune")
+	ErrLocationType       = errors.New("Unknown location type")
+	ErrLSPPosition        = errors.New("Position is not available in the retained source")
+	ErrUnterminatedQuote  = errors.New("Unterminated quoted string")
+	ErrUnterminatedEscape = errors.New("Escape character at end of input")
+	ErrBidiControl        = errors.New("Bidirectional control character")
+	ErrZeroWidth          = errors.New("Zero-width character")
+	ErrMixedScript        = errors.New("Letter from a different script than the rest of the word")
+	ErrControlChar        = errors.New("Control character in input")
+	ErrMixedLineEndings   = errors.New("Line ending differs from the first line ending")
+	ErrPeekOffset         = errors.New("Negative peek offset")
 )
 
 // EncodingErrorHandler is an interface for an encoding error handler.
@@ -30,15 +47,19 @@ var (
 // must return either nil or an error (which may be the same error).
 type EncodingErrorHandler interface {
 	// Handle handles the reported encoding error.  If it returns
-	// non-nil, the scanner will report an error.
+	// nil, the scanner will replace the invalid input with the
+	// Unicode replacement character, U+FFFD.  If it returns
+	// ErrSkipInvalid, the scanner will skip the invalid input.
+	// Otherwise, the scanner will report the returned error.
 	Handle(e error) error
 }
 
 // locationError is an implementation of error that wraps an error and
 // includes a location.
 type locationError struct {
-	loc Location // The location of the error
-	err error    // The error that occurred there
+	loc   Location       // The location of the error
+	err   error          // The error that occurred there
+	inner *locationError // Wrapped location error superseded by loc
 }
 
 // LocationError wraps an error and includes a location.
@@ -54,10 +75,44 @@ func LocationError(loc Location, err error) error {
 	}
 }
 
+// relocateError is a helper that replaces the location of an error
+// that has one.  If the locationError is not at the top of the error
+// chain, the whole error is wrapped with the new location, so that
+// LocationOf reports the new location; the superseded location is
+// omitted from the error message.
+func relocateError(err error, loc Location) error {
+	if le, ok := err.(*locationError); ok {
+		return &locationError{
+			loc:   loc,
+			err:   le.err,
+			inner: le.inner,
+		}
+	}
+
+	var inner *locationError
+	errors.As(err, &inner)
+	return &locationError{
+		loc:   loc,
+		err:   err,
+		inner: inner,
+	}
+}
+
 // Error returns the error message for a locationError.  This
 // implementation prefixes the error message with the location.
 func (le *locationError) Error() string {
-	return fmt.Sprintf("%s: %s", le.loc, le.err)
+	return fmt.Sprintf("%s: %s", le.loc, le.message())
+}
+
+// message is a helper for Error that returns the error message of the
+// wrapped error, omitting the location of any superseded location
+// error.
+func (le *locationError) message() string {
+	if le.inner == nil {
+		return le.err.Error()
+	}
+
+	return strings.Replace(le.err.Error(), le.inner.Error(), le.inner.message(), 1)
 }
 
 // Unwrap allows unwrapping the locationError to retrieve the
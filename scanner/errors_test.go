@@ -53,6 +53,77 @@ func TestLocationErrorNoLocation(t *testing.T) {
 	assert.Same(t, assert.AnError, result)
 }
 
+func TestRelocateErrorBase(t *testing.T) {
+	loc1 := &mockLocation{}
+	loc2 := &mockLocation{}
+
+	result := relocateError(&locationError{loc: loc1, err: assert.AnError}, loc2)
+
+	assert.Equal(t, &locationError{
+		loc: loc2,
+		err: assert.AnError,
+	}, result)
+}
+
+func TestRelocateErrorWrapped(t *testing.T) {
+	loc1 := &mockLocation{}
+	loc2 := &mockLocation{}
+	err := fmt.Errorf("wrapped: %w", &locationError{loc: loc1, err: assert.AnError})
+
+	result := relocateError(err, loc2)
+
+	assert.Equal(t, &locationError{
+		loc:   loc2,
+		err:   err,
+		inner: &locationError{loc: loc1, err: assert.AnError},
+	}, result)
+	assert.Same(t, loc2, LocationOf(result))
+}
+
+func TestRelocateErrorRelocated(t *testing.T) {
+	loc1 := &mockLocation{}
+	loc2 := &mockLocation{}
+	loc3 := &mockLocation{}
+	inner := &locationError{loc: loc1, err: assert.AnError}
+	err := &locationError{loc: loc2, err: fmt.Errorf("wrapped: %w", inner), inner: inner}
+
+	result := relocateError(err, loc3)
+
+	assert.Equal(t, &locationError{
+		loc:   loc3,
+		err:   err.err,
+		inner: inner,
+	}, result)
+}
+
+func TestLocationErrorErrorRelocated(t *testing.T) {
+	loc1 := &mockLocation{}
+	loc1.On("String").Return("stale")
+	loc2 := &mockLocation{}
+	loc2.On("String").Return("location")
+	err := fmt.Errorf("wrapped: %w", &locationError{loc: loc1, err: assert.AnError})
+
+	result := relocateError(err, loc2)
+
+	assert.Equal(t, fmt.Sprintf("location: wrapped: %s", assert.AnError), result.Error())
+}
+
+func TestLocationErrorErrorRelocatedTwice(t *testing.T) {
+	loc1 := &mockLocation{}
+	loc1.On("String").Return("stale")
+	loc2 := &mockLocation{}
+	loc2.On("String").Return("also stale")
+	loc3 := &mockLocation{}
+	loc3.On("String").Return("location")
+	err := fmt.Errorf("wrapped: %w", &locationError{loc: loc1, err: assert.AnError})
+	err = fmt.Errorf("again: %w", relocateError(err, loc2))
+
+	result := relocateError(err, loc3)
+
+	assert.Equal(t, fmt.Sprintf("location: again: wrapped: %s", assert.AnError), result.Error())
+	assert.Same(t, loc3, LocationOf(result))
+}
+
 func TestLocationErrorError(t *testing.T) {
 	loc := &mockLocation{}
 	loc.On("String").Return("location")
@@ -16,26 +16,44 @@ package scanner
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"unicode/utf8"
 )
 
 // FilePos specifies a particular character location within a file.
 // It is a component of the FileLocation type.
 type FilePos struct {
-	L int // The line number of the position (1-indexed)
-	C int // The column number of the position (1-indexed)
+	L int `json:"line"`   // The line number of the position (1-indexed)
+	C int `json:"col"`    // The column number of the position (1-indexed)
+	O int `json:"offset"` // The byte offset of the position (0-indexed)
+	R int `json:"rune"`   // The rune offset of the position (0-indexed)
+}
+
+// cmp compares the position with another position by line and
+// column.
+func (p FilePos) cmp(o FilePos) int {
+	if p.L != o.L {
+		return compareInts(p.L, o.L)
+	}
+
+	return compareInts(p.C, o.C)
 }
 
 // FileLocation is an implementation of Location that identifies the
 // location of an element within a file.  It represents a full range,
 // and has some additional utilities to simplify handling advancement
-// within the file, including of tab stops.
+// within the file, including of tab stops.  FileLocation also
+// implements OffsetLocation, which allows it to track the byte and
+// rune offsets of the range within the file.
 type FileLocation struct {
-	File string  // Name of the file
-	B    FilePos // The beginning of the range
-	E    FilePos // The end of the range
+	File string  `json:"file"`  // Name of the file
+	B    FilePos `json:"begin"` // The beginning of the range
+	E    FilePos `json:"end"`   // The end of the range
 }
 
 // String constructs a string representation of the location.
@@ -102,6 +120,8 @@ func (l FileLocation) advance(offset FilePos) Location {
 		l.E.C = 1
 	}
 	l.E.C += offset.C
+	l.E.O += offset.O
+	l.E.R += offset.R
 
 	// Return the new location
 	return l
@@ -109,26 +129,151 @@ func (l FileLocation) advance(offset FilePos) Location {
 
 // Incr increments the location by one character.  It is passed the
 // character (a rune) and the tabstop size (for handling tabs).  It
-// should return a new Location.
+// should return a new Location.  The offsets are advanced as if the
+// character were encoded in UTF-8, and the character is assumed to
+// occupy a single column.
 func (l FileLocation) Incr(c rune, tabstop int) Location {
+	// Compute the extent of the character
+	ext := Extent{}
+	if c != EOF {
+		ext.Runes = 1
+		ext.Cols = 1
+		if ext.Bytes = utf8.RuneLen(c); ext.Bytes < 0 {
+			ext.Bytes = utf8.RuneLen(utf8.RuneError)
+		}
+	}
+
+	return l.IncrExtent(c, tabstop, ext)
+}
+
+// IncrExtent is similar to Incr, except that it is also passed the
+// extent of the source consumed by the character.  The extent is used
+// to advance the byte and rune offsets, and the column for characters
+// other than tabs and newlines.
+func (l FileLocation) IncrExtent(c rune, tabstop int, ext Extent) Location {
+	offset := FilePos{O: ext.Bytes, R: ext.Runes}
 	switch c {
 	case EOF: // End of file
-		return l.advance(FilePos{})
 
 	case '\n': // Newline
-		return l.advance(FilePos{L: 1})
+		offset.L = 1
 
 	case '\t': // Tab
-		return l.advance(FilePos{C: 1 + tabstop - l.E.C%tabstop})
+		offset.C = 1 + tabstop - l.E.C%tabstop
 
 	case '\f': // Skip formfeeds at the beginning of lines
 		if l.B.C <= 1 {
+			l.E.O += offset.O
+			l.E.R += offset.R
 			return l
 		}
 		fallthrough
-	default: // Everything else advances by one column
-		return l.advance(FilePos{C: 1})
+	default: // Everything else advances by its width
+		offset.C = ext.Cols
+	}
+
+	return l.advance(offset)
+}
+
+// same is a helper that checks to see if another location is a
+// FileLocation in the same file.
+func (l FileLocation) same(other Location) (FileLocation, bool) {
+	o, ok := other.(FileLocation)
+	return o, ok && l.File == o.File
+}
+
+// Compare compares this location with another location.  It returns
+// a negative number if this location sorts before the other location,
+// a positive number if it sorts after, and 0 if the locations are the
+// same.  Locations in different files are ordered by file name.
+func (l FileLocation) Compare(other Location) (int, error) {
+	o, ok := other.(FileLocation)
+	switch {
+	case !ok:
+		return 0, ErrSplitLocation
+	case l.File != o.File:
+		return strings.Compare(l.File, o.File), nil
+	case l.B.cmp(o.B) != 0:
+		return l.B.cmp(o.B), nil
+	}
+
+	return l.E.cmp(o.E), nil
+}
+
+// Before returns true if this location ends at or before the
+// beginning of another location.
+func (l FileLocation) Before(other Location) bool {
+	o, ok := l.same(other)
+	return ok && l.E.cmp(o.B) <= 0
+}
+
+// Contains returns true if another location lies entirely within this
+// location.
+func (l FileLocation) Contains(other Location) bool {
+	o, ok := l.same(other)
+	return ok && l.B.cmp(o.B) <= 0 && o.E.cmp(l.E) <= 0
+}
+
+// Overlaps returns true if this location and another location have
+// any characters in common.  An empty location overlaps another
+// location if it lies within it.
+func (l FileLocation) Overlaps(other Location) bool {
+	o, ok := l.same(other)
+	return ok && (l.B.cmp(o.E) < 0 && o.B.cmp(l.E) < 0 || l.Contains(o) || o.Contains(l))
+}
+
+// Union creates a new Location that ranges from the earlier of the
+// beginnings of this location and another location to the later of
+// their endings.
+func (l FileLocation) Union(other Location) (Location, error) {
+	// Location can't range across files
+	o, ok := l.same(other)
+	if !ok {
+		return nil, ErrSplitLocation
+	}
+
+	if o.B.cmp(l.B) < 0 {
+		l.B = o.B
+	}
+	if o.E.cmp(l.E) > 0 {
+		l.E = o.E
+	}
+
+	return l, nil
+}
+
+// MarshalJSON encodes the location as JSON.  The encoding includes a
+// "type" field, which allows UnmarshalLocation to decode it.
+func (l FileLocation) MarshalJSON() ([]byte, error) {
+	type plain FileLocation
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		plain
+	}{
+		Type:  FileLocationType,
+		plain: plain(l),
+	})
+}
+
+// UnmarshalJSON decodes the location from JSON.  If the encoding
+// includes a "type" field, it must designate a FileLocation.
+func (l *FileLocation) UnmarshalJSON(data []byte) error {
+	type plain FileLocation
+	tmp := struct {
+		Type string `json:"type"`
+		*plain
+	}{
+		plain: (*plain)(l),
+	}
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+
+	if tmp.Type != "" && tmp.Type != FileLocationType {
+		return ErrLocationType
 	}
+
+	return nil
 }
 
 // DefaultTabStop is the default tab stop for the scanner.
@@ -150,8 +295,26 @@ type FileScanner struct {
 	ts    int                  // The tabstop in use
 	ls    LineStyle            // Current line ending style
 	saved rune                 // One-character pushback for line endings
+	ext   Extent               // Extent of the character being read
+	last  Extent               // Extent of the last rune decoded
+	sext  Extent               // Extent of the saved character
 	loc   Location             // Location of head of read buffer
 	enc   EncodingErrorHandler // Handler for encoding errors
+	dec   Decoder              // Decoder for the source encoding
+	bom   bool                 // Detect encoding from byte order mark
+	reg   *SourceRegistry      // Registry to retain text in
+	rec   *Source              // Source retaining the text
+	cu    ColumnUnit           // Unit for counting columns
+	lc    rune                 // Line continuation escape character
+	pend  *Char                // Pending character after an escape
+	perr  error                // Error for the pending character
+	ctx   context.Context      // Context to monitor for cancellation
+	max   int64                // Maximum number of bytes to read
+	total int64                // Total number of bytes read
+	cls   io.Closer            // Closer to call when the source is done
+	san   *SanitizePolicy      // Policy for sanitizing the input
+	eol   int                  // Kind of the first line ending
+	crLoc Location             // Location of an unresolved carriage return
 }
 
 // NewFileScanner constructs a new instance of the FileScanner.
@@ -171,12 +334,256 @@ func NewFileScanner(r io.Reader, loc Location, options ...FileOption) *FileScann
 		opt.fileApply(s)
 	}
 
+	// Set up text retention
+	if fl, ok := loc.(FileLocation); ok && s.reg != nil {
+		s.rec = s.reg.open(fl.File, s.ts)
+		s.rec.Unit = s.cu
+	}
+
 	return s
 }
 
+// fill reads more data from the source into the read buffer.  Any
+// unread portion of the buffer is first shifted to the beginning of
+// the buffer.
+func (s *FileScanner) fill() {
+	// Shift unread portion to beginning
+	copy(s.buf[0:], s.buf[s.pos:s.end])
+
+	// Read more
+	bufLen := s.end - s.pos
+	readLen, err := s.src.Read(s.buf[bufLen:scanBuf])
+	s.pos = 0
+	s.end = bufLen + readLen
+	s.total += int64(readLen)
+	s.buf[s.end] = utf8.RuneSelf // mark end of buffer
+
+	// Did we get an error?
+	if err != nil {
+		// Save the error
+		if err != io.EOF {
+			s.err = err
+		}
+
+		// Mark closed
+		s.stop()
+	}
+}
+
+// stop is a helper that marks the source as exhausted.  If the
+// scanner was constructed with a source that must be closed, it is
+// closed at this point.
+func (s *FileScanner) stop() {
+	s.src = nil
+
+	if s.cls != nil {
+		if err := s.cls.Close(); err != nil && s.err == nil {
+			s.err = err
+		}
+		s.cls = nil
+	}
+}
+
+// Close stops the scanner before the end of the source has been
+// reached.  If the scanner was constructed with a source that must be
+// closed, such as by Open or OpenFS, it is closed, and any error from
+// closing it is returned.  After Close, Next returns EOF.
+func (s *FileScanner) Close() error {
+	s.pos = s.end
+	s.err = nil
+	s.stop()
+
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// resume is a helper that allows scanning to continue after the
+// source has been exhausted, drawing characters from the designated
+// source.  Any EOF held for line ending or line continuation handling
+// is dropped.  It returns false if the scanner has been stopped by an
+// error, in which case it cannot be resumed.
+func (s *FileScanner) resume(src io.Reader) bool {
+	if s.err != nil {
+		return false
+	}
+
+	if s.src == nil {
+		s.src = src
+	}
+	if s.saved == EOF {
+		s.saved = sentinel
+	}
+	if s.pend != nil && s.pend.Rune == EOF && s.perr == nil {
+		s.pend = nil
+	}
+
+	return true
+}
+
+// reset is a helper that discards all buffered input, along with any
+// character held for line ending or line continuation handling, and
+// then resumes scanning from the designated source, as for resume.
+func (s *FileScanner) reset(src io.Reader) bool {
+	s.pos = s.end
+	s.saved = sentinel
+	s.ext, s.last, s.sext = Extent{}, Extent{}, Extent{}
+	s.pend, s.perr = nil, nil
+	s.crLoc = nil
+
+	return s.resume(src)
+}
+
+// sniff is a helper for next that detects the encoding of the source
+// from its byte order mark, if it has one.  The byte order mark is
+// skipped, and is included in the extent of the first character.
+func (s *FileScanner) sniff() {
+	// Make sure we have enough data to check
+	for s.end-s.pos < len(bomUTF8) && s.src != nil {
+		s.fill()
+	}
+
+	// Check for a byte order mark
+	p := s.buf[s.pos:s.end]
+	var mark []byte
+	switch {
+	case bytes.HasPrefix(p, bomUTF8):
+		s.dec, mark = nil, bomUTF8
+	case bytes.HasPrefix(p, bomUTF16LE):
+		s.dec, mark = UTF16LE, bomUTF16LE
+	case bytes.HasPrefix(p, bomUTF16BE):
+		s.dec, mark = UTF16BE, bomUTF16BE
+	default:
+		return
+	}
+
+	// Skip it
+	s.pos += len(mark)
+	s.ext.Bytes += len(mark)
+	s.ext.Runes++
+}
+
+// decode is a helper for next that decodes the next character using
+// the configured Decoder.
+func (s *FileScanner) decode() rune {
+	// Make sure we have a full character
+	for !s.dec.FullRune(s.buf[s.pos:s.end]) && s.src != nil {
+		s.fill()
+	}
+
+	// Check for end of file
+	if s.pos >= s.end {
+		if s.err == nil {
+			return EOF
+		}
+		return errRune
+	}
+
+	// Decode the character
+	ch, width, err := s.dec.DecodeRune(s.buf[s.pos:s.end])
+	if err != nil {
+		if ch = s.handle(err, width); ch != utf8.RuneError {
+			return ch
+		}
+	}
+
+	// Update buffer position
+	s.pos += width
+	s.last = Extent{Bytes: width, Runes: 1}
+	s.ext.Bytes += width
+	s.ext.Runes++
+
+	return ch
+}
+
+// handle is a helper for next and decode that reports an encoding
+// error, covering the designated number of bytes, to the encoding
+// error handler.  It returns utf8.RuneError if the invalid input
+// should be replaced, skipRune if it was skipped, or errRune if the
+// error should be reported.
+func (s *FileScanner) handle(err error, width int) rune {
+	// Compute the location of the invalid input
+	var loc Location
+	if ol, ok := s.loc.(OffsetLocation); ok {
+		ext := Extent{Bytes: s.ext.Bytes + width, Runes: s.ext.Runes + 1}
+		ext.Cols = s.cu.Columns(utf8.RuneError, ext)
+		loc = ol.IncrExtent(utf8.RuneError, s.ts, ext)
+	} else {
+		loc = s.loc.Incr(utf8.RuneError, s.ts)
+	}
+	err = LocationError(loc, err)
+
+	// If we have a handler, call it
+	if s.enc != nil {
+		err = s.enc.Handle(err)
+	}
+
+	switch {
+	case err == nil: // Replace the invalid input
+		return utf8.RuneError
+
+	case errors.Is(err, ErrSkipInvalid): // Skip the invalid input
+		s.pos += width
+		s.ext.Bytes += width
+		s.ext.Runes++
+		return skipRune
+
+	default: // Handler didn't handle it
+		s.err = err
+		return errRune
+	}
+}
+
 // next is the inner implementation of the scan algorithm.  It returns
 // the next character read from the source as a rune.
 func (s *FileScanner) next() rune {
+	// Read characters until one isn't skipped
+	for {
+		ch := s.read()
+
+		// Check the input size
+		if s.max > 0 && ch != EOF && ch != errRune && s.total-int64(s.end-s.pos) > s.max {
+			s.err = LocationError(s.loc.Incr(EOF, s.ts), ErrInputTooLarge)
+
+			// Stop reading the source
+			s.stop()
+			s.pos = s.end
+			return errRune
+		}
+
+		// Apply the sanitization policy
+		if ch = s.sanitize(ch); ch != skipRune {
+			return ch
+		}
+	}
+}
+
+// read is a helper for next that reads a single character from the
+// source.  It returns skipRune if invalid input was skipped.
+func (s *FileScanner) read() rune {
+	s.last = Extent{}
+
+	// Check for cancellation
+	if s.ctx != nil {
+		select {
+		case <-s.ctx.Done():
+			s.err = LocationError(s.loc.Incr(EOF, s.ts), s.ctx.Err())
+			return errRune
+		default:
+		}
+	}
+
+	// Detect the encoding, if requested
+	if s.bom {
+		s.bom = false
+		s.sniff()
+	}
+
+	// Use the decoder, if one was selected
+	if s.dec != nil {
+		return s.decode()
+	}
+
 	// Convert next byte into a rune; optimized for the common
 	// case
 	ch, width := rune(s.buf[s.pos]), 1
@@ -195,26 +602,7 @@ func (s *FileScanner) next() rune {
 				return errRune
 			}
 
-			// Shift unread portion to beginning
-			copy(s.buf[0:], s.buf[s.pos:s.end])
-
-			// Read more
-			bufLen := s.end - s.pos
-			readLen, err := s.src.Read(s.buf[bufLen:scanBuf])
-			s.pos = 0
-			s.end = bufLen + readLen
-			s.buf[s.end] = utf8.RuneSelf // mark end of buffer
-
-			// Did we get an error?
-			if err != nil {
-				// Mark closed
-				s.src = nil
-
-				// Save the error
-				if err != io.EOF {
-					s.err = err
-				}
-			}
+			s.fill()
 		}
 
 		// OK, try a rune conversion again
@@ -225,18 +613,8 @@ func (s *FileScanner) next() rune {
 
 			// Was it a decoding error?
 			if ch == utf8.RuneError && width == 1 {
-				err := LocationError(s.loc.Incr(ch, s.ts), ErrBadEncoding)
-
-				// If we have a handler, call it
-				if s.enc != nil {
-					err = s.enc.Handle(err)
-				}
-
-				// If handler didn't handle it, return
-				// the error
-				if err != nil {
-					s.err = err
-					return errRune
+				if ch = s.handle(ErrBadEncoding, width); ch != utf8.RuneError {
+					return ch
 				}
 			}
 		}
@@ -244,46 +622,83 @@ func (s *FileScanner) next() rune {
 
 	// Update buffer position
 	s.pos += width
+	s.last = Extent{Bytes: width, Runes: 1}
+	s.ext.Bytes += width
+	s.ext.Runes++
 
 	return ch
 }
 
+// save saves the last rune decoded for the next call to Next.  The
+// extent of the rune is removed from the extent of the character
+// being read.
+func (s *FileScanner) save(ch rune) {
+	s.saved = ch
+	s.sext = s.last
+	s.ext.Bytes -= s.last.Bytes
+	s.ext.Runes -= s.last.Runes
+}
+
+// isTerminator is a helper for Next that checks to see if a character
+// begins a line ending sequence.
+func (s *FileScanner) isTerminator(ch rune) bool {
+	return isTerminator(s.ls, ch)
+}
+
 // Next returns the next character from the stream as a Char, which
 // will include the character's location.  If an error was
 // encountered, that will also be returned.
 func (s *FileScanner) Next() (Char, error) {
+	ch, err := s.pull()
+
+	// Splice out line continuations
+	for s.lc != 0 && ch.Rune == s.lc && err == nil {
+		next, nextErr := s.pull()
+		if next.Rune != '\n' || nextErr != nil {
+			s.pend, s.perr = &next, nextErr
+			break
+		}
+
+		ch, err = s.pull()
+	}
+
+	return ch, err
+}
+
+// pull is a helper for Next that returns the pending character, if
+// there is one, or reads the next character from the source.
+func (s *FileScanner) pull() (Char, error) {
+	if s.pend != nil {
+		ch, err := *s.pend, s.perr
+		s.pend, s.perr = nil, nil
+		return ch, err
+	}
+
+	return s.char()
+}
+
+// char is a helper for Next that reads the next character from the
+// source, handling line endings and computing the character's
+// location.
+func (s *FileScanner) char() (Char, error) {
 	// Select the next character to process
 	var ch rune
 	if s.saved != sentinel {
 		ch, s.saved = s.saved, sentinel
+		s.ext = s.sext
 	} else {
+		s.ext = Extent{}
 		// Get the next character
 		ch = s.next()
 	}
 
-	// If it's CR or NL, do line ending handling
-	if ch == '\r' || ch == '\n' {
-		seq := []rune{ch}
-		for {
-			dis, ls := s.ls.Handle(seq)
-			switch dis {
-			case LineDisNewline:
-				ch = '\n'
-			case LineDisNewlineSave:
-				ch = '\n'
-				s.saved = seq[1]
-			case LineDisSpace:
-				ch = ' '
-				if len(seq) > 1 {
-					s.saved = seq[1]
-				}
-			case LineDisMore:
-				seq = append(seq, s.next())
-				continue
-			}
-
-			s.ls = ls
-			break
+	// If it's a line terminator, do line ending handling
+	var seq []rune
+	if s.isTerminator(ch) {
+		var unread bool
+		ch, seq, unread, s.ls = lineEnding(s.ls, ch, s.next)
+		if unread {
+			s.save(seq[len(seq)-1])
 		}
 	}
 
@@ -294,8 +709,23 @@ func (s *FileScanner) Next() (Char, error) {
 		err = s.err
 	}
 
+	// Retain the character
+	if s.rec != nil && ch != EOF {
+		s.rec.append(ch)
+	}
+
 	// Increment the location by the character
-	s.loc = s.loc.Incr(ch, s.ts)
+	if ol, ok := s.loc.(OffsetLocation); ok {
+		s.ext.Cols = s.cu.Columns(ch, s.ext)
+		s.loc = ol.IncrExtent(ch, s.ts, s.ext)
+	} else {
+		s.loc = s.loc.Incr(ch, s.ts)
+	}
+
+	// Check the sanitization policy
+	if err == nil {
+		err = s.check(ch, s.loc, seq)
+	}
 
 	return Char{
 		Rune: ch,
@@ -16,22 +16,200 @@ package scanner
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"testing"
 	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFileLocationImplementsLocation(t *testing.T) {
 	assert.Implements(t, (*Location)(nil), &FileLocation{})
 }
 
+func TestFilePosCmp(t *testing.T) {
+	p := FilePos{L: 2, C: 5}
+
+	assert.Equal(t, 0, p.cmp(FilePos{L: 2, C: 5, O: 42}))
+	assert.Equal(t, -1, p.cmp(FilePos{L: 2, C: 6}))
+	assert.Equal(t, 1, p.cmp(FilePos{L: 2, C: 4}))
+	assert.Equal(t, -1, p.cmp(FilePos{L: 3, C: 1}))
+	assert.Equal(t, 1, p.cmp(FilePos{L: 1, C: 9}))
+}
+
+func TestFileLocationImplementsOrderedLocation(t *testing.T) {
+	assert.Implements(t, (*OrderedLocation)(nil), FileLocation{})
+}
+
+// fileLoc is a helper for constructing FileLocation values on a
+// single line.
+func fileLoc(file string, line, b, e int) FileLocation {
+	return FileLocation{
+		File: file,
+		B:    FilePos{L: line, C: b},
+		E:    FilePos{L: line, C: e},
+	}
+}
+
+func TestFileLocationSame(t *testing.T) {
+	loc := fileLoc("file", 1, 2, 3)
+
+	o, ok := loc.same(fileLoc("file", 2, 2, 3))
+	assert.True(t, ok)
+	assert.Equal(t, fileLoc("file", 2, 2, 3), o)
+	_, ok = loc.same(fileLoc("other", 1, 2, 3))
+	assert.False(t, ok)
+	_, ok = loc.same(ArgLocation{})
+	assert.False(t, ok)
+}
+
+func TestFileLocationCompare(t *testing.T) {
+	loc := fileLoc("file", 2, 3, 5)
+
+	for other, expected := range map[Location]int{
+		fileLoc("file", 2, 3, 5): 0,
+		fileLoc("file", 2, 3, 6): -1,
+		fileLoc("file", 2, 3, 4): 1,
+		fileLoc("file", 2, 4, 4): -1,
+		fileLoc("file", 1, 9, 9): 1,
+		fileLoc("aaaa", 3, 1, 1): 1,
+		fileLoc("zzzz", 1, 1, 1): -1,
+	} {
+		result, err := loc.Compare(other)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, result, "%s", other)
+	}
+}
+
+func TestFileLocationCompareIncompatible(t *testing.T) {
+	loc := fileLoc("file", 2, 3, 5)
+
+	result, err := loc.Compare(ArgLocation{})
+
+	assert.Same(t, ErrSplitLocation, err)
+	assert.Equal(t, 0, result)
+}
+
+func TestFileLocationBefore(t *testing.T) {
+	loc := fileLoc("file", 2, 3, 5)
+
+	assert.True(t, loc.Before(fileLoc("file", 2, 5, 6)))
+	assert.True(t, loc.Before(fileLoc("file", 3, 1, 2)))
+	assert.False(t, loc.Before(fileLoc("file", 2, 4, 6)))
+	assert.False(t, loc.Before(fileLoc("file", 1, 1, 2)))
+	assert.False(t, loc.Before(fileLoc("other", 3, 1, 2)))
+	assert.False(t, loc.Before(ArgLocation{}))
+}
+
+func TestFileLocationContains(t *testing.T) {
+	loc := fileLoc("file", 2, 3, 7)
+
+	assert.True(t, loc.Contains(fileLoc("file", 2, 3, 7)))
+	assert.True(t, loc.Contains(fileLoc("file", 2, 4, 5)))
+	assert.True(t, loc.Contains(fileLoc("file", 2, 7, 7)))
+	assert.False(t, loc.Contains(fileLoc("file", 2, 2, 5)))
+	assert.False(t, loc.Contains(fileLoc("file", 2, 5, 8)))
+	assert.False(t, loc.Contains(fileLoc("other", 2, 4, 5)))
+	assert.False(t, loc.Contains(ArgLocation{}))
+}
+
+func TestFileLocationOverlaps(t *testing.T) {
+	loc := fileLoc("file", 2, 3, 7)
+
+	assert.True(t, loc.Overlaps(fileLoc("file", 2, 1, 4)))
+	assert.True(t, loc.Overlaps(fileLoc("file", 2, 6, 9)))
+	assert.True(t, loc.Overlaps(fileLoc("file", 2, 1, 9)))
+	assert.True(t, loc.Overlaps(fileLoc("file", 2, 4, 4)))
+	assert.True(t, fileLoc("file", 2, 4, 4).Overlaps(loc))
+	assert.False(t, loc.Overlaps(fileLoc("file", 2, 1, 3)))
+	assert.False(t, loc.Overlaps(fileLoc("file", 2, 7, 9)))
+	assert.False(t, loc.Overlaps(fileLoc("other", 2, 1, 4)))
+	assert.False(t, loc.Overlaps(ArgLocation{}))
+}
+
+func TestFileLocationUnion(t *testing.T) {
+	loc := fileLoc("file", 2, 3, 7)
+
+	result, err := loc.Union(fileLoc("file", 2, 5, 9))
+	assert.NoError(t, err)
+	assert.Equal(t, fileLoc("file", 2, 3, 9), result)
+	result, err = loc.Union(fileLoc("file", 2, 1, 4))
+	assert.NoError(t, err)
+	assert.Equal(t, fileLoc("file", 2, 1, 7), result)
+}
+
+func TestFileLocationUnionIncompatible(t *testing.T) {
+	loc := fileLoc("file", 2, 3, 7)
+
+	result, err := loc.Union(fileLoc("other", 2, 5, 9))
+
+	assert.Same(t, ErrSplitLocation, err)
+	assert.Nil(t, result)
+}
+
+func TestFileLocationMarshalJSON(t *testing.T) {
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 2, O: 1, R: 1},
+		E:    FilePos{L: 1, C: 3, O: 2, R: 2},
+	}
+
+	result, err := json.Marshal(loc)
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"file","file":"file","begin":{"line":1,"col":2,"offset":1,"rune":1},"end":{"line":1,"col":3,"offset":2,"rune":2}}`, string(result))
+}
+
+func TestFileLocationUnmarshalJSON(t *testing.T) {
+	loc := FileLocation{}
+
+	err := json.Unmarshal([]byte(`{"file":"file","begin":{"line":1,"col":2},"end":{"line":1,"col":3}}`), &loc)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fileLoc("file", 1, 2, 3), loc)
+}
+
+func TestFileLocationUnmarshalJSONWrongType(t *testing.T) {
+	loc := FileLocation{}
+
+	err := json.Unmarshal([]byte(`{"type":"arg"}`), &loc)
+
+	assert.Same(t, ErrLocationType, err)
+}
+
+func TestFileLocationUnmarshalJSONBad(t *testing.T) {
+	loc := FileLocation{}
+
+	err := json.Unmarshal([]byte(`{"file":1}`), &loc)
+
+	assert.Error(t, err)
+}
+
+func TestFileLocationJSONRoundTrip(t *testing.T) {
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 2, O: 1, R: 1},
+		E:    FilePos{L: 3, C: 4, O: 10, R: 9},
+	}
+	data, err := json.Marshal(loc)
+	require.NoError(t, err)
+
+	result, err := UnmarshalLocation(data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, loc, result)
+}
+
 func TestFileLocationString0Columns(t *testing.T) {
 	loc := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 2},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 2},
 	}
 
 	result := loc.String()
@@ -42,8 +220,8 @@ func TestFileLocationString0Columns(t *testing.T) {
 func TestFileLocationString1Column(t *testing.T) {
 	loc := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}
 
 	result := loc.String()
@@ -54,8 +232,8 @@ func TestFileLocationString1Column(t *testing.T) {
 func TestFileLocationString2Columns(t *testing.T) {
 	loc := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 4},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 4},
 	}
 
 	result := loc.String()
@@ -66,8 +244,8 @@ func TestFileLocationString2Columns(t *testing.T) {
 func TestFileLocationString2Lines(t *testing.T) {
 	loc := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{4, 2},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 4, C: 2},
 	}
 
 	result := loc.String()
@@ -78,13 +256,13 @@ func TestFileLocationString2Lines(t *testing.T) {
 func TestFileLocationThruBase(t *testing.T) {
 	loc1 := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}
 	loc2 := FileLocation{
 		File: "file",
-		B:    FilePos{3, 5},
-		E:    FilePos{3, 6},
+		B:    FilePos{L: 3, C: 5},
+		E:    FilePos{L: 3, C: 6},
 	}
 
 	result, err := loc1.Thru(loc2)
@@ -92,21 +270,21 @@ func TestFileLocationThruBase(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 5},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 5},
 	}, result)
 }
 
 func TestFileLocationThruSplitFile(t *testing.T) {
 	loc1 := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}
 	loc2 := FileLocation{
 		File: "other",
-		B:    FilePos{3, 5},
-		E:    FilePos{3, 6},
+		B:    FilePos{L: 3, C: 5},
+		E:    FilePos{L: 3, C: 6},
 	}
 
 	result, err := loc1.Thru(loc2)
@@ -118,8 +296,8 @@ func TestFileLocationThruSplitFile(t *testing.T) {
 func TestFileLocationThruSplitLocation(t *testing.T) {
 	loc1 := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}
 	loc2 := &mockLocation{}
 
@@ -132,13 +310,13 @@ func TestFileLocationThruSplitLocation(t *testing.T) {
 func TestFileLocationThruEndBase(t *testing.T) {
 	loc1 := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}
 	loc2 := FileLocation{
 		File: "file",
-		B:    FilePos{3, 5},
-		E:    FilePos{3, 6},
+		B:    FilePos{L: 3, C: 5},
+		E:    FilePos{L: 3, C: 6},
 	}
 
 	result, err := loc1.ThruEnd(loc2)
@@ -146,21 +324,21 @@ func TestFileLocationThruEndBase(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 6},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 6},
 	}, result)
 }
 
 func TestFileLocationThruEndSplitFile(t *testing.T) {
 	loc1 := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}
 	loc2 := FileLocation{
 		File: "other",
-		B:    FilePos{3, 5},
-		E:    FilePos{3, 6},
+		B:    FilePos{L: 3, C: 5},
+		E:    FilePos{L: 3, C: 6},
 	}
 
 	result, err := loc1.ThruEnd(loc2)
@@ -172,8 +350,8 @@ func TestFileLocationThruEndSplitFile(t *testing.T) {
 func TestFileLocationThruEndSplitLocation(t *testing.T) {
 	loc1 := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}
 	loc2 := &mockLocation{}
 
@@ -186,189 +364,273 @@ func TestFileLocationThruEndSplitLocation(t *testing.T) {
 func TestFileLocationAdvanceColumn(t *testing.T) {
 	loc := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}
 
 	result := loc.advance(FilePos{C: 2})
 
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 3},
-		E:    FilePos{3, 5},
+		B:    FilePos{L: 3, C: 3},
+		E:    FilePos{L: 3, C: 5},
 	}, result)
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}, loc)
 }
 
 func TestFileLocationAdvanceLine(t *testing.T) {
 	loc := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}
 
 	result := loc.advance(FilePos{L: 1, C: 2})
 
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 3},
-		E:    FilePos{4, 3},
+		B:    FilePos{L: 3, C: 3},
+		E:    FilePos{L: 4, C: 3},
 	}, result)
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}, loc)
 }
 
+func TestFileLocationImplementsOffsetLocation(t *testing.T) {
+	assert.Implements(t, (*OffsetLocation)(nil), &FileLocation{})
+}
+
+func TestFileLocationAdvanceOffsets(t *testing.T) {
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 3, C: 2, O: 10, R: 8},
+		E:    FilePos{L: 3, C: 3, O: 11, R: 9},
+	}
+
+	result := loc.advance(FilePos{L: 1, O: 2, R: 2})
+
+	assert.Equal(t, FileLocation{
+		File: "file",
+		B:    FilePos{L: 3, C: 3, O: 11, R: 9},
+		E:    FilePos{L: 4, C: 1, O: 13, R: 11},
+	}, result)
+}
+
+func TestFileLocationIncrMultibyte(t *testing.T) {
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 3, C: 2, O: 1, R: 1},
+		E:    FilePos{L: 3, C: 3, O: 2, R: 2},
+	}
+
+	result := loc.Incr('\u00f1', 8)
+
+	assert.Equal(t, FileLocation{
+		File: "file",
+		B:    FilePos{L: 3, C: 3, O: 2, R: 2},
+		E:    FilePos{L: 3, C: 4, O: 4, R: 3},
+	}, result)
+}
+
+func TestFileLocationIncrInvalidRune(t *testing.T) {
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
+	}
+
+	result := loc.Incr(0xd800, 8)
+
+	assert.Equal(t, FileLocation{
+		File: "file",
+		B:    FilePos{L: 3, C: 3},
+		E:    FilePos{L: 3, C: 4, O: 3, R: 1},
+	}, result)
+}
+
+func TestFileLocationIncrExtentBase(t *testing.T) {
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 3, C: 2, O: 1, R: 1},
+		E:    FilePos{L: 3, C: 3, O: 2, R: 2},
+	}
+
+	result := loc.IncrExtent('\n', 8, Extent{Bytes: 2, Runes: 2})
+
+	assert.Equal(t, FileLocation{
+		File: "file",
+		B:    FilePos{L: 3, C: 3, O: 2, R: 2},
+		E:    FilePos{L: 4, C: 1, O: 4, R: 4},
+	}, result)
+}
+
+func TestFileLocationIncrExtentFormFeed(t *testing.T) {
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 3, C: 1, O: 1, R: 1},
+		E:    FilePos{L: 3, C: 1, O: 1, R: 1},
+	}
+
+	result := loc.IncrExtent('\f', 8, Extent{Bytes: 1, Runes: 1})
+
+	assert.Equal(t, FileLocation{
+		File: "file",
+		B:    FilePos{L: 3, C: 1, O: 1, R: 1},
+		E:    FilePos{L: 3, C: 1, O: 2, R: 2},
+	}, result)
+}
+
 func TestFileLocationIncrBase(t *testing.T) {
 	loc := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}
 
 	result := loc.Incr('c', 8)
 
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 3},
-		E:    FilePos{3, 4},
+		B:    FilePos{L: 3, C: 3},
+		E:    FilePos{L: 3, C: 4, O: 1, R: 1},
 	}, result)
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}, loc)
 }
 
 func TestFileLocationIncrEOF(t *testing.T) {
 	loc := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}
 
 	result := loc.Incr(EOF, 8)
 
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 3},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 3},
+		E:    FilePos{L: 3, C: 3},
 	}, result)
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}, loc)
 }
 
 func TestFileLocationIncrNewline(t *testing.T) {
 	loc := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}
 
 	result := loc.Incr('\n', 8)
 
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 3},
-		E:    FilePos{4, 1},
+		B:    FilePos{L: 3, C: 3},
+		E:    FilePos{L: 4, C: 1, O: 1, R: 1},
 	}, result)
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}, loc)
 }
 
 func TestFileLocationIncrTab8(t *testing.T) {
 	loc := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}
 
 	result := loc.Incr('\t', 8)
 
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 3},
-		E:    FilePos{3, 9},
+		B:    FilePos{L: 3, C: 3},
+		E:    FilePos{L: 3, C: 9, O: 1, R: 1},
 	}, result)
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}, loc)
 }
 
 func TestFileLocationIncrTab4(t *testing.T) {
 	loc := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}
 
 	result := loc.Incr('\t', 4)
 
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 3},
-		E:    FilePos{3, 5},
+		B:    FilePos{L: 3, C: 3},
+		E:    FilePos{L: 3, C: 5, O: 1, R: 1},
 	}, result)
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}, loc)
 }
 
 func TestFileLocationIncrFormFeedMidLine(t *testing.T) {
 	loc := FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}
 
 	result := loc.Incr('\f', 8)
 
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 3},
-		E:    FilePos{3, 4},
+		B:    FilePos{L: 3, C: 3},
+		E:    FilePos{L: 3, C: 4, O: 1, R: 1},
 	}, result)
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 2},
-		E:    FilePos{3, 3},
+		B:    FilePos{L: 3, C: 2},
+		E:    FilePos{L: 3, C: 3},
 	}, loc)
 }
 
 func TestFileLocationIncrFormFeedBeginningOfLine(t *testing.T) {
 	loc := FileLocation{
 		File: "file",
-		B:    FilePos{3, 1},
-		E:    FilePos{3, 2},
+		B:    FilePos{L: 3, C: 1},
+		E:    FilePos{L: 3, C: 2},
 	}
 
 	result := loc.Incr('\f', 8)
 
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 1},
-		E:    FilePos{3, 2},
+		B:    FilePos{L: 3, C: 1},
+		E:    FilePos{L: 3, C: 2, O: 1, R: 1},
 	}, result)
 	assert.Equal(t, FileLocation{
 		File: "file",
-		B:    FilePos{3, 1},
-		E:    FilePos{3, 2},
+		B:    FilePos{L: 3, C: 1},
+		E:    FilePos{L: 3, C: 2},
 	}, loc)
 }
 
@@ -398,6 +660,30 @@ func TestNewFileScanner(t *testing.T) {
 	opt2.AssertExpectations(t)
 }
 
+func TestNewFileScannerRetain(t *testing.T) {
+	src := &bytes.Buffer{}
+	loc := FileLocation{File: "file"}
+	reg := NewSourceRegistry()
+
+	result := NewFileScanner(src, loc, Retain(reg), TabStop(4))
+
+	assert.Same(t, reg, result.reg)
+	assert.Same(t, reg.Source("file"), result.rec)
+	assert.Equal(t, 4, result.rec.TabStop)
+}
+
+func TestNewFileScannerRetainOtherLocation(t *testing.T) {
+	src := &bytes.Buffer{}
+	loc := &mockLocation{}
+	reg := NewSourceRegistry()
+
+	result := NewFileScanner(src, loc, Retain(reg))
+
+	assert.Same(t, reg, result.reg)
+	assert.Nil(t, result.rec)
+	assert.Equal(t, map[string]*Source{}, reg.sources)
+}
+
 func TestFileScannerNextCharBufferedASCII(t *testing.T) {
 	obj := &FileScanner{
 		src: &bytes.Buffer{},
@@ -482,6 +768,56 @@ func TestFileScannerNextCharBufferedBadCharHandled(t *testing.T) {
 	handler.AssertExpectations(t)
 }
 
+func TestFileScannerNextCharBufferedBadCharSkipped(t *testing.T) {
+	chrLoc := &mockLocation{}
+	loc := &mockLocation{}
+	loc.On("Incr", utf8.RuneError, DefaultTabStop).Return(chrLoc)
+	handler := &mockEncodingErrorHandler{}
+	handler.On("Handle", LocationError(chrLoc, ErrBadEncoding)).Return(ErrSkipInvalid)
+	obj := &FileScanner{
+		src: &bytes.Buffer{},
+		buf: [scanBuf + 1]byte{128, 'b', 'u', 'f', utf8.RuneSelf},
+		end: 4,
+		ts:  DefaultTabStop,
+		loc: loc,
+		enc: handler,
+	}
+
+	c := obj.next()
+
+	assert.Equal(t, 'b', c)
+	assert.NotNil(t, obj.src)
+	assert.Equal(t, 2, obj.pos)
+	assert.Equal(t, 4, obj.end)
+	assert.Equal(t, Extent{Bytes: 1, Runes: 1}, obj.last)
+	assert.Equal(t, Extent{Bytes: 2, Runes: 2}, obj.ext)
+	assert.Nil(t, obj.err)
+	loc.AssertExpectations(t)
+	handler.AssertExpectations(t)
+}
+
+func TestFileScannerHandleOffsetLocation(t *testing.T) {
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1, O: 0, R: 0},
+		E:    FilePos{L: 1, C: 2, O: 1, R: 1},
+	}
+	obj := &FileScanner{
+		ts:  DefaultTabStop,
+		ext: Extent{Bytes: 2, Runes: 1},
+		loc: loc,
+	}
+
+	c := obj.handle(ErrBadEncoding, 1)
+
+	assert.Equal(t, errRune, c)
+	assert.Equal(t, LocationError(FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 2, O: 1, R: 1},
+		E:    FilePos{L: 1, C: 3, O: 4, R: 3},
+	}, ErrBadEncoding), obj.err)
+}
+
 func TestFileScannerNextCharBufferedBadCharChanged(t *testing.T) {
 	chrLoc := &mockLocation{}
 	loc := &mockLocation{}
@@ -620,6 +956,127 @@ func (r *mockReader) Read(b []byte) (int, error) {
 	return args.Int(1), args.Error(2)
 }
 
+type mockCloser struct {
+	mock.Mock
+}
+
+func (c *mockCloser) Close() error {
+	args := c.MethodCalled("Close")
+
+	return args.Error(0)
+}
+
+func TestFileScannerStopBase(t *testing.T) {
+	obj := &FileScanner{
+		src: &bytes.Buffer{},
+	}
+
+	obj.stop()
+
+	assert.Nil(t, obj.src)
+	assert.NoError(t, obj.err)
+}
+
+func TestFileScannerStopCloser(t *testing.T) {
+	cls := &mockCloser{}
+	cls.On("Close").Return(nil)
+	obj := &FileScanner{
+		src: &bytes.Buffer{},
+		cls: cls,
+	}
+
+	obj.stop()
+
+	assert.Nil(t, obj.src)
+	assert.Nil(t, obj.cls)
+	assert.NoError(t, obj.err)
+	cls.AssertExpectations(t)
+}
+
+func TestFileScannerStopCloserError(t *testing.T) {
+	cls := &mockCloser{}
+	cls.On("Close").Return(assert.AnError)
+	obj := &FileScanner{
+		src: &bytes.Buffer{},
+		cls: cls,
+	}
+
+	obj.stop()
+
+	assert.Nil(t, obj.src)
+	assert.Nil(t, obj.cls)
+	assert.Same(t, assert.AnError, obj.err)
+	cls.AssertExpectations(t)
+}
+
+func TestFileScannerStopCloserDeferredError(t *testing.T) {
+	cls := &mockCloser{}
+	cls.On("Close").Return(errors.New("close"))
+	obj := &FileScanner{
+		src: &bytes.Buffer{},
+		err: assert.AnError,
+		cls: cls,
+	}
+
+	obj.stop()
+
+	assert.Nil(t, obj.src)
+	assert.Nil(t, obj.cls)
+	assert.Same(t, assert.AnError, obj.err)
+	cls.AssertExpectations(t)
+}
+
+func TestFileScannerCloseBase(t *testing.T) {
+	cls := &mockCloser{}
+	cls.On("Close").Return(nil)
+	obj := &FileScanner{
+		src: &bytes.Buffer{},
+		pos: 1,
+		end: 3,
+		err: assert.AnError,
+		cls: cls,
+	}
+
+	err := obj.Close()
+
+	assert.NoError(t, err)
+	assert.Nil(t, obj.src)
+	assert.Nil(t, obj.cls)
+	assert.Equal(t, 3, obj.pos)
+	assert.NoError(t, obj.err)
+	cls.AssertExpectations(t)
+}
+
+func TestFileScannerCloseError(t *testing.T) {
+	cls := &mockCloser{}
+	cls.On("Close").Return(assert.AnError)
+	obj := &FileScanner{
+		src: &bytes.Buffer{},
+		cls: cls,
+	}
+
+	err := obj.Close()
+
+	assert.Same(t, assert.AnError, err)
+	assert.Nil(t, obj.src)
+	assert.NoError(t, obj.err)
+	cls.AssertExpectations(t)
+}
+
+func TestFileScannerCloseNext(t *testing.T) {
+	obj := NewFileScanner(bytes.NewBufferString("test"), FileLocation{})
+
+	ch, err := obj.Next()
+	require.NoError(t, err)
+	assert.Equal(t, 't', ch.Rune)
+	err = obj.Close()
+	require.NoError(t, err)
+	ch, err = obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, EOF, ch.Rune)
+}
+
 func TestFileScannerNextCharReadErrorDelayed(t *testing.T) {
 	src := &mockReader{}
 	src.On("Read").Return([]byte{'t', 'e', 's', 't'}, 4, assert.AnError)
@@ -657,6 +1114,357 @@ func TestFileScannerNextCharReadErrorImmediate(t *testing.T) {
 	assert.Same(t, assert.AnError, obj.err)
 }
 
+func TestFileScannerSniffNoBOM(t *testing.T) {
+	obj := &FileScanner{
+		src: bytes.NewBufferString("test"),
+		buf: [scanBuf + 1]byte{utf8.RuneSelf},
+		dec: Latin1,
+	}
+
+	obj.sniff()
+
+	assert.Same(t, Latin1, obj.dec)
+	assert.Equal(t, 0, obj.pos)
+	assert.Equal(t, 4, obj.end)
+	assert.Equal(t, Extent{}, obj.ext)
+}
+
+func TestFileScannerSniffShort(t *testing.T) {
+	obj := &FileScanner{
+		src: bytes.NewBufferString("a"),
+		buf: [scanBuf + 1]byte{utf8.RuneSelf},
+		dec: Latin1,
+	}
+
+	obj.sniff()
+
+	assert.Same(t, Latin1, obj.dec)
+	assert.Nil(t, obj.src)
+	assert.Equal(t, 0, obj.pos)
+	assert.Equal(t, 1, obj.end)
+}
+
+func TestFileScannerSniffUTF8(t *testing.T) {
+	obj := &FileScanner{
+		src: bytes.NewBuffer([]byte{0xef, 0xbb, 0xbf, 'a'}),
+		buf: [scanBuf + 1]byte{utf8.RuneSelf},
+		dec: Latin1,
+	}
+
+	obj.sniff()
+
+	assert.Nil(t, obj.dec)
+	assert.Equal(t, 3, obj.pos)
+	assert.Equal(t, Extent{Bytes: 3, Runes: 1}, obj.ext)
+}
+
+func TestFileScannerSniffUTF16LE(t *testing.T) {
+	obj := &FileScanner{
+		src: bytes.NewBuffer([]byte{0xff, 0xfe, 'a', 0}),
+		buf: [scanBuf + 1]byte{utf8.RuneSelf},
+	}
+
+	obj.sniff()
+
+	assert.Same(t, UTF16LE, obj.dec)
+	assert.Equal(t, 2, obj.pos)
+	assert.Equal(t, Extent{Bytes: 2, Runes: 1}, obj.ext)
+}
+
+func TestFileScannerSniffUTF16BE(t *testing.T) {
+	obj := &FileScanner{
+		src: bytes.NewBuffer([]byte{0xfe, 0xff, 0, 'a'}),
+		buf: [scanBuf + 1]byte{utf8.RuneSelf},
+	}
+
+	obj.sniff()
+
+	assert.Same(t, UTF16BE, obj.dec)
+	assert.Equal(t, 2, obj.pos)
+	assert.Equal(t, Extent{Bytes: 2, Runes: 1}, obj.ext)
+}
+
+func TestFileScannerDecodeBase(t *testing.T) {
+	obj := &FileScanner{
+		src: bytes.NewBuffer([]byte{'a', 0, 'b', 0}),
+		buf: [scanBuf + 1]byte{utf8.RuneSelf},
+		dec: UTF16LE,
+	}
+
+	c := obj.decode()
+
+	assert.Equal(t, 'a', c)
+	assert.Equal(t, 2, obj.pos)
+	assert.Equal(t, 4, obj.end)
+	assert.Equal(t, Extent{Bytes: 2, Runes: 1}, obj.last)
+	assert.Equal(t, Extent{Bytes: 2, Runes: 1}, obj.ext)
+}
+
+func TestFileScannerDecodeEOF(t *testing.T) {
+	obj := &FileScanner{
+		buf: [scanBuf + 1]byte{utf8.RuneSelf},
+		dec: UTF16LE,
+	}
+
+	c := obj.decode()
+
+	assert.Equal(t, EOF, c)
+}
+
+func TestFileScannerDecodeError(t *testing.T) {
+	obj := &FileScanner{
+		buf: [scanBuf + 1]byte{utf8.RuneSelf},
+		err: assert.AnError,
+		dec: UTF16LE,
+	}
+
+	c := obj.decode()
+
+	assert.Equal(t, errRune, c)
+	assert.Same(t, assert.AnError, obj.err)
+}
+
+func TestFileScannerDecodeBadCharBase(t *testing.T) {
+	chrLoc := &mockLocation{}
+	loc := &mockLocation{}
+	loc.On("Incr", utf8.RuneError, DefaultTabStop).Return(chrLoc)
+	obj := &FileScanner{
+		buf: [scanBuf + 1]byte{'a', utf8.RuneSelf},
+		end: 1,
+		ts:  DefaultTabStop,
+		loc: loc,
+		dec: UTF16LE,
+	}
+
+	c := obj.decode()
+
+	assert.Equal(t, errRune, c)
+	assert.Equal(t, 0, obj.pos)
+	assert.Equal(t, LocationError(chrLoc, ErrBadUTF16), obj.err)
+	loc.AssertExpectations(t)
+}
+
+func TestFileScannerDecodeBadCharHandled(t *testing.T) {
+	chrLoc := &mockLocation{}
+	loc := &mockLocation{}
+	loc.On("Incr", utf8.RuneError, DefaultTabStop).Return(chrLoc)
+	handler := &mockEncodingErrorHandler{}
+	handler.On("Handle", LocationError(chrLoc, ErrBadUTF16)).Return(nil)
+	obj := &FileScanner{
+		buf: [scanBuf + 1]byte{'a', utf8.RuneSelf},
+		end: 1,
+		ts:  DefaultTabStop,
+		loc: loc,
+		enc: handler,
+		dec: UTF16LE,
+	}
+
+	c := obj.decode()
+
+	assert.Equal(t, utf8.RuneError, c)
+	assert.Equal(t, 1, obj.pos)
+	assert.Nil(t, obj.err)
+	loc.AssertExpectations(t)
+	handler.AssertExpectations(t)
+}
+
+func TestFileScannerNextCharDecoder(t *testing.T) {
+	obj := &FileScanner{
+		src: bytes.NewBuffer([]byte{0xf1}),
+		buf: [scanBuf + 1]byte{utf8.RuneSelf},
+		dec: Latin1,
+	}
+
+	c := obj.next()
+
+	assert.Equal(t, '\u00f1', c)
+	assert.Equal(t, 1, obj.pos)
+}
+
+func TestFileScannerNextCharDetect(t *testing.T) {
+	obj := &FileScanner{
+		src: bytes.NewBuffer([]byte{0xfe, 0xff, 0, 0xf1}),
+		buf: [scanBuf + 1]byte{utf8.RuneSelf},
+		bom: true,
+	}
+
+	c := obj.next()
+
+	assert.Equal(t, '\u00f1', c)
+	assert.False(t, obj.bom)
+	assert.Same(t, UTF16BE, obj.dec)
+	assert.Equal(t, Extent{Bytes: 4, Runes: 2}, obj.ext)
+}
+
+func TestFileScannerNextUTF16(t *testing.T) {
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}
+	obj := NewFileScanner(bytes.NewBuffer([]byte{
+		0xff, 0xfe, 'a', 0, '\r', 0, '\n', 0, 0x3d, 0xd8, 0x00, 0xde,
+	}), loc, DetectEncoding(nil))
+	result := []Char{}
+
+	for {
+		ch, err := obj.Next()
+		assert.NoError(t, err)
+		result = append(result, ch)
+		if ch.Rune == EOF {
+			break
+		}
+	}
+
+	assert.Equal(t, []Char{
+		{
+			Rune: 'a',
+			Loc: FileLocation{
+				File: "file",
+				B:    FilePos{L: 1, C: 1, O: 0, R: 0},
+				E:    FilePos{L: 1, C: 2, O: 4, R: 2},
+			},
+		},
+		{
+			Rune: '\n',
+			Loc: FileLocation{
+				File: "file",
+				B:    FilePos{L: 1, C: 2, O: 4, R: 2},
+				E:    FilePos{L: 2, C: 1, O: 8, R: 4},
+			},
+		},
+		{
+			Rune: '\U0001f600',
+			Loc: FileLocation{
+				File: "file",
+				B:    FilePos{L: 2, C: 1, O: 8, R: 4},
+				E:    FilePos{L: 2, C: 2, O: 12, R: 5},
+			},
+		},
+		{
+			Rune: EOF,
+			Loc: FileLocation{
+				File: "file",
+				B:    FilePos{L: 2, C: 2, O: 12, R: 5},
+				E:    FilePos{L: 2, C: 2, O: 12, R: 5},
+			},
+		},
+	}, result)
+}
+
+func TestFileScannerSave(t *testing.T) {
+	obj := &FileScanner{
+		ext:  Extent{Bytes: 3, Runes: 2},
+		last: Extent{Bytes: 2, Runes: 1},
+	}
+
+	obj.save('x')
+
+	assert.Equal(t, 'x', obj.saved)
+	assert.Equal(t, Extent{Bytes: 1, Runes: 1}, obj.ext)
+	assert.Equal(t, Extent{Bytes: 2, Runes: 1}, obj.sext)
+}
+
+func TestFileScannerNextOffsets(t *testing.T) {
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}
+	obj := NewFileScanner(bytes.NewBufferString("a\r\n\u00f1\r\rb"), loc)
+	result := []FileLocation{}
+
+	for {
+		ch, err := obj.Next()
+		assert.NoError(t, err)
+		result = append(result, ch.Loc.(FileLocation))
+		if ch.Rune == EOF {
+			break
+		}
+	}
+
+	assert.Equal(t, []FileLocation{
+		{
+			File: "file",
+			B:    FilePos{L: 1, C: 1, O: 0, R: 0},
+			E:    FilePos{L: 1, C: 2, O: 1, R: 1},
+		},
+		{
+			File: "file",
+			B:    FilePos{L: 1, C: 2, O: 1, R: 1},
+			E:    FilePos{L: 2, C: 1, O: 3, R: 3},
+		},
+		{
+			File: "file",
+			B:    FilePos{L: 2, C: 1, O: 3, R: 3},
+			E:    FilePos{L: 2, C: 2, O: 5, R: 4},
+		},
+		{
+			File: "file",
+			B:    FilePos{L: 2, C: 2, O: 5, R: 4},
+			E:    FilePos{L: 2, C: 3, O: 6, R: 5},
+		},
+		{
+			File: "file",
+			B:    FilePos{L: 2, C: 3, O: 6, R: 5},
+			E:    FilePos{L: 2, C: 4, O: 7, R: 6},
+		},
+		{
+			File: "file",
+			B:    FilePos{L: 2, C: 4, O: 7, R: 6},
+			E:    FilePos{L: 2, C: 5, O: 8, R: 7},
+		},
+		{
+			File: "file",
+			B:    FilePos{L: 2, C: 5, O: 8, R: 7},
+			E:    FilePos{L: 2, C: 5, O: 8, R: 7},
+		},
+	}, result)
+}
+
+func TestFileScannerNextColumns(t *testing.T) {
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}
+	input := "a\u4e2d\U0001f600\tb"
+	expected := map[ColumnUnit][]int{
+		ColumnRunes: {1, 2, 3, 4, 9, 10},
+		ColumnBytes: {1, 2, 5, 9, 17, 18},
+		ColumnUTF16: {1, 2, 3, 5, 9, 10},
+		ColumnWidth: {1, 2, 4, 6, 9, 10},
+	}
+
+	for unit, cols := range expected {
+		obj := NewFileScanner(bytes.NewBufferString(input), loc, unit)
+		result := []int{1}
+		for {
+			ch, err := obj.Next()
+			require.NoError(t, err)
+			if ch.Rune == EOF {
+				break
+			}
+			result = append(result, ch.Loc.(FileLocation).E.C)
+		}
+
+		assert.Equal(t, cols, result, "unit %d", unit)
+	}
+}
+
+func TestFileScannerNextColumnsRetain(t *testing.T) {
+	reg := NewSourceRegistry()
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}
+
+	NewFileScanner(bytes.NewBufferString(""), loc, Retain(reg), ColumnUTF16)
+
+	assert.Equal(t, ColumnUTF16, reg.Source("file").Unit)
+}
+
 func TestFileScannerNextBase(t *testing.T) {
 	nextLoc := &mockLocation{}
 	loc := &mockLocation{}
@@ -909,3 +1717,213 @@ func TestFileScannerNextDisMoreSpace(t *testing.T) {
 	loc.AssertExpectations(t)
 	ls.AssertExpectations(t)
 }
+
+func TestFileScannerIsTerminatorBase(t *testing.T) {
+	obj := &FileScanner{ls: UNIXLineStyle}
+
+	assert.True(t, obj.isTerminator('\r'))
+	assert.True(t, obj.isTerminator('\n'))
+	assert.False(t, obj.isTerminator(LineSeparator))
+	assert.False(t, obj.isTerminator('a'))
+}
+
+func TestFileScannerIsTerminatorUnicode(t *testing.T) {
+	obj := &FileScanner{ls: UnicodeLineStyle}
+
+	assert.True(t, obj.isTerminator('\r'))
+	assert.True(t, obj.isTerminator('\n'))
+	assert.True(t, obj.isTerminator(LineSeparator))
+	assert.False(t, obj.isTerminator('a'))
+}
+
+func TestFileScannerNextUnicodeLines(t *testing.T) {
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}
+	obj := NewFileScanner(bytes.NewBufferString("a\u2028b\u0085\r\nc\u2029\rd"), loc, LineEndings(UnicodeLineStyle))
+	result := []Char{}
+
+	for {
+		ch, err := obj.Next()
+		assert.NoError(t, err)
+		if ch.Rune == EOF {
+			break
+		}
+		result = append(result, ch)
+	}
+
+	assert.Equal(t, []Char{
+		{Rune: 'a', Loc: FileLocation{File: "file", B: FilePos{L: 1, C: 1, O: 0, R: 0}, E: FilePos{L: 1, C: 2, O: 1, R: 1}}},
+		{Rune: '\n', Loc: FileLocation{File: "file", B: FilePos{L: 1, C: 2, O: 1, R: 1}, E: FilePos{L: 2, C: 1, O: 4, R: 2}}},
+		{Rune: 'b', Loc: FileLocation{File: "file", B: FilePos{L: 2, C: 1, O: 4, R: 2}, E: FilePos{L: 2, C: 2, O: 5, R: 3}}},
+		{Rune: '\n', Loc: FileLocation{File: "file", B: FilePos{L: 2, C: 2, O: 5, R: 3}, E: FilePos{L: 3, C: 1, O: 7, R: 4}}},
+		{Rune: '\n', Loc: FileLocation{File: "file", B: FilePos{L: 3, C: 1, O: 7, R: 4}, E: FilePos{L: 4, C: 1, O: 9, R: 6}}},
+		{Rune: 'c', Loc: FileLocation{File: "file", B: FilePos{L: 4, C: 1, O: 9, R: 6}, E: FilePos{L: 4, C: 2, O: 10, R: 7}}},
+		{Rune: '\n', Loc: FileLocation{File: "file", B: FilePos{L: 4, C: 2, O: 10, R: 7}, E: FilePos{L: 5, C: 1, O: 13, R: 8}}},
+		{Rune: '\n', Loc: FileLocation{File: "file", B: FilePos{L: 5, C: 1, O: 13, R: 8}, E: FilePos{L: 6, C: 1, O: 14, R: 9}}},
+		{Rune: 'd', Loc: FileLocation{File: "file", B: FilePos{L: 6, C: 1, O: 14, R: 9}, E: FilePos{L: 6, C: 2, O: 15, R: 10}}},
+	}, result)
+}
+
+func TestFileScannerNextPending(t *testing.T) {
+	loc := &mockLocation{}
+	obj := &FileScanner{
+		pend: &Char{Rune: 'x', Loc: loc},
+		perr: assert.AnError,
+	}
+
+	ch, err := obj.Next()
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, Char{Rune: 'x', Loc: loc}, ch)
+	assert.Nil(t, obj.pend)
+	assert.Nil(t, obj.perr)
+}
+
+func TestFileScannerNextContinuation(t *testing.T) {
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}
+	obj := NewFileScanner(bytes.NewBufferString("a\\\nb\\c\\\\\nd\\"), loc, LineContinuation('\\'))
+	result := []Char{}
+
+	for {
+		ch, err := obj.Next()
+		assert.NoError(t, err)
+		result = append(result, ch)
+		if ch.Rune == EOF {
+			break
+		}
+	}
+
+	assert.Equal(t, []Char{
+		{Rune: 'a', Loc: FileLocation{File: "file", B: FilePos{L: 1, C: 1, O: 0, R: 0}, E: FilePos{L: 1, C: 2, O: 1, R: 1}}},
+		{Rune: 'b', Loc: FileLocation{File: "file", B: FilePos{L: 2, C: 1, O: 3, R: 3}, E: FilePos{L: 2, C: 2, O: 4, R: 4}}},
+		{Rune: '\\', Loc: FileLocation{File: "file", B: FilePos{L: 2, C: 2, O: 4, R: 4}, E: FilePos{L: 2, C: 3, O: 5, R: 5}}},
+		{Rune: 'c', Loc: FileLocation{File: "file", B: FilePos{L: 2, C: 3, O: 5, R: 5}, E: FilePos{L: 2, C: 4, O: 6, R: 6}}},
+		{Rune: '\\', Loc: FileLocation{File: "file", B: FilePos{L: 2, C: 4, O: 6, R: 6}, E: FilePos{L: 2, C: 5, O: 7, R: 7}}},
+		{Rune: 'd', Loc: FileLocation{File: "file", B: FilePos{L: 3, C: 1, O: 9, R: 9}, E: FilePos{L: 3, C: 2, O: 10, R: 10}}},
+		{Rune: '\\', Loc: FileLocation{File: "file", B: FilePos{L: 3, C: 2, O: 10, R: 10}, E: FilePos{L: 3, C: 3, O: 11, R: 11}}},
+		{Rune: EOF, Loc: FileLocation{File: "file", B: FilePos{L: 3, C: 3, O: 11, R: 11}, E: FilePos{L: 3, C: 3, O: 11, R: 11}}},
+	}, result)
+}
+
+func TestFileScannerNextContinuationRetain(t *testing.T) {
+	reg := NewSourceRegistry()
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}
+	obj := NewFileScanner(bytes.NewBufferString("ab\\\ncd"), loc, LineContinuation('\\'), Retain(reg))
+	first, _ := obj.Next()
+	for ch, _ := obj.Next(); ch.Rune != EOF; ch, _ = obj.Next() {
+		first.Loc, _ = first.Loc.ThruEnd(ch.Loc)
+	}
+
+	assert.Equal(t, "ab\\\ncd", reg.Source("file").Text())
+	assert.Equal(t, "file:1:1-2:3", first.Loc.String())
+}
+
+func TestFileScannerReadCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	chrLoc := &mockLocation{}
+	loc := &mockLocation{}
+	loc.On("Incr", EOF, DefaultTabStop).Return(chrLoc)
+	obj := &FileScanner{
+		src: &bytes.Buffer{},
+		buf: [scanBuf + 1]byte{'b', 'u', 'f', utf8.RuneSelf},
+		end: 3,
+		ts:  DefaultTabStop,
+		loc: loc,
+		ctx: ctx,
+	}
+
+	c := obj.read()
+
+	assert.Equal(t, errRune, c)
+	assert.Equal(t, 0, obj.pos)
+	assert.Equal(t, LocationError(chrLoc, context.Canceled), obj.err)
+	loc.AssertExpectations(t)
+}
+
+func TestFileScannerNextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}
+	obj := NewFileScanner(bytes.NewBufferString("abc"), loc, Context(ctx))
+
+	ch, err := obj.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, 'a', ch.Rune)
+	cancel()
+	ch, err = obj.Next()
+
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, "file:1:2: context canceled", err.Error())
+	assert.Equal(t, EOF, ch.Rune)
+}
+
+func TestFileScannerNextMaxInputSize(t *testing.T) {
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}
+	obj := NewFileScanner(bytes.NewBufferString("ab\u00f1c"), loc, MaxInputSize(3))
+
+	ch, err := obj.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, 'a', ch.Rune)
+	ch, err = obj.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, 'b', ch.Rune)
+	ch, err = obj.Next()
+
+	assert.True(t, errors.Is(err, ErrInputTooLarge))
+	assert.Equal(t, "file:1:3: Input exceeds maximum size", err.Error())
+	assert.Equal(t, EOF, ch.Rune)
+	assert.Nil(t, obj.src)
+	ch, err = obj.Next()
+	assert.True(t, errors.Is(err, ErrInputTooLarge))
+	assert.Equal(t, EOF, ch.Rune)
+}
+
+func TestFileScannerNextMaxInputSizeExact(t *testing.T) {
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}
+	obj := NewFileScanner(bytes.NewBufferString("ab\u00f1"), loc, MaxInputSize(4))
+
+	for _, r := range []rune{'a', 'b', '\u00f1', EOF} {
+		ch, err := obj.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, r, ch.Rune)
+	}
+}
+
+func TestFileScannerNextRetain(t *testing.T) {
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}
+	reg := NewSourceRegistry()
+	obj := NewFileScanner(bytes.NewBufferString("ab\r\ncd"), loc, Retain(reg))
+
+	for ch, err := obj.Next(); ch.Rune != EOF; ch, err = obj.Next() {
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, "ab\ncd", reg.Source("file").Text())
+}
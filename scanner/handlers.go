@@ -0,0 +1,133 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"sync"
+)
+
+// EncodingErrorHandlerFunc is an adapter allowing an ordinary function
+// to be used as an EncodingErrorHandler.
+type EncodingErrorHandlerFunc func(e error) error
+
+// Handle handles the reported encoding error.  It calls the function.
+func (f EncodingErrorHandlerFunc) Handle(e error) error {
+	return f(e)
+}
+
+// replaceHandler is an EncodingErrorHandler that replaces invalid
+// input with the Unicode replacement character.
+type replaceHandler struct{}
+
+// Handle handles the reported encoding error.
+func (h *replaceHandler) Handle(e error) error {
+	return nil
+}
+
+// skipHandler is an EncodingErrorHandler that skips invalid input.
+type skipHandler struct{}
+
+// Handle handles the reported encoding error.
+func (h *skipHandler) Handle(e error) error {
+	return ErrSkipInvalid
+}
+
+// Standard encoding error handlers.
+var (
+	// ReplaceInvalid replaces invalid input with the Unicode
+	// replacement character, U+FFFD, and continues scanning.
+	ReplaceInvalid EncodingErrorHandler = &replaceHandler{}
+
+	// SkipInvalid skips invalid input and continues scanning.
+	SkipInvalid EncodingErrorHandler = &skipHandler{}
+)
+
+// ErrorCollector is an EncodingErrorHandler that collects all the
+// encoding errors reported to it, then delegates the handling of each
+// error to another EncodingErrorHandler.  The collected errors carry
+// the location of the invalid input, which may be retrieved using
+// LocationOf.
+type ErrorCollector struct {
+	mu   sync.Mutex           // Protects the errors
+	next EncodingErrorHandler // Handler to delegate to
+	errs []error              // The collected errors
+}
+
+// NewErrorCollector constructs a new ErrorCollector.  Errors will be
+// delegated to the specified handler; if that handler is nil, invalid
+// input will be replaced by the Unicode replacement character.
+func NewErrorCollector(next EncodingErrorHandler) *ErrorCollector {
+	return &ErrorCollector{
+		next: next,
+	}
+}
+
+// Handle handles the reported encoding error.
+func (c *ErrorCollector) Handle(e error) error {
+	c.mu.Lock()
+	c.errs = append(c.errs, e)
+	c.mu.Unlock()
+
+	if c.next != nil {
+		return c.next.Handle(e)
+	}
+
+	return nil
+}
+
+// Errors returns the list of errors collected so far.
+func (c *ErrorCollector) Errors() []error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]error{}, c.errs...)
+}
+
+// errorLimit is an EncodingErrorHandler that fails once a limit on
+// the number of encoding errors has been exceeded.
+type errorLimit struct {
+	mu    sync.Mutex           // Protects the count
+	limit int                  // The number of errors to tolerate
+	count int                  // The number of errors seen
+	next  EncodingErrorHandler // Handler to delegate to
+}
+
+// LimitErrors returns an EncodingErrorHandler that tolerates the
+// specified number of encoding errors, delegating their handling to
+// another EncodingErrorHandler; if that handler is nil, invalid input
+// will be replaced by the Unicode replacement character.  Once the
+// limit is exceeded, the encoding error is reported by the scanner.
+func LimitErrors(limit int, next EncodingErrorHandler) EncodingErrorHandler {
+	return &errorLimit{
+		limit: limit,
+		next:  next,
+	}
+}
+
+// Handle handles the reported encoding error.
+func (h *errorLimit) Handle(e error) error {
+	h.mu.Lock()
+	h.count++
+	exceeded := h.count > h.limit
+	h.mu.Unlock()
+
+	if exceeded {
+		return e
+	} else if h.next != nil {
+		return h.next.Handle(e)
+	}
+
+	return nil
+}
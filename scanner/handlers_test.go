@@ -0,0 +1,238 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodingErrorHandlerFuncImplementsEncodingErrorHandler(t *testing.T) {
+	assert.Implements(t, (*EncodingErrorHandler)(nil), EncodingErrorHandlerFunc(nil))
+}
+
+func TestEncodingErrorHandlerFuncHandle(t *testing.T) {
+	var called error
+	obj := EncodingErrorHandlerFunc(func(e error) error {
+		called = e
+		return ErrSkipInvalid
+	})
+
+	result := obj.Handle(assert.AnError)
+
+	assert.Same(t, ErrSkipInvalid, result)
+	assert.Same(t, assert.AnError, called)
+}
+
+func TestReplaceInvalidHandle(t *testing.T) {
+	result := ReplaceInvalid.Handle(assert.AnError)
+
+	assert.NoError(t, result)
+}
+
+func TestSkipInvalidHandle(t *testing.T) {
+	result := SkipInvalid.Handle(assert.AnError)
+
+	assert.Same(t, ErrSkipInvalid, result)
+}
+
+func TestErrorCollectorImplementsEncodingErrorHandler(t *testing.T) {
+	assert.Implements(t, (*EncodingErrorHandler)(nil), &ErrorCollector{})
+}
+
+func TestNewErrorCollector(t *testing.T) {
+	result := NewErrorCollector(SkipInvalid)
+
+	assert.Equal(t, &ErrorCollector{next: SkipInvalid}, result)
+}
+
+func TestErrorCollectorHandleBase(t *testing.T) {
+	obj := &ErrorCollector{errs: []error{ErrBadUTF16}}
+
+	result := obj.Handle(assert.AnError)
+
+	assert.NoError(t, result)
+	assert.Equal(t, []error{ErrBadUTF16, assert.AnError}, obj.errs)
+}
+
+func TestErrorCollectorHandleNext(t *testing.T) {
+	next := &mockEncodingErrorHandler{}
+	next.On("Handle", assert.AnError).Return(ErrSkipInvalid)
+	obj := &ErrorCollector{next: next}
+
+	result := obj.Handle(assert.AnError)
+
+	assert.Same(t, ErrSkipInvalid, result)
+	assert.Equal(t, []error{assert.AnError}, obj.errs)
+	next.AssertExpectations(t)
+}
+
+func TestErrorCollectorErrors(t *testing.T) {
+	obj := &ErrorCollector{errs: []error{ErrBadUTF16, assert.AnError}}
+
+	result := obj.Errors()
+
+	assert.Equal(t, []error{ErrBadUTF16, assert.AnError}, result)
+	result[0] = nil
+	assert.Equal(t, []error{ErrBadUTF16, assert.AnError}, obj.errs)
+}
+
+func TestErrorCollectorErrorsEmpty(t *testing.T) {
+	obj := &ErrorCollector{}
+
+	result := obj.Errors()
+
+	assert.Equal(t, []error{}, result)
+}
+
+func TestLimitErrors(t *testing.T) {
+	result := LimitErrors(3, SkipInvalid)
+
+	assert.Equal(t, &errorLimit{
+		limit: 3,
+		next:  SkipInvalid,
+	}, result)
+}
+
+func TestErrorLimitHandleBase(t *testing.T) {
+	obj := &errorLimit{limit: 2, count: 1}
+
+	result := obj.Handle(assert.AnError)
+
+	assert.NoError(t, result)
+	assert.Equal(t, 2, obj.count)
+}
+
+func TestErrorLimitHandleNext(t *testing.T) {
+	next := &mockEncodingErrorHandler{}
+	next.On("Handle", assert.AnError).Return(ErrSkipInvalid)
+	obj := &errorLimit{limit: 2, count: 1, next: next}
+
+	result := obj.Handle(assert.AnError)
+
+	assert.Same(t, ErrSkipInvalid, result)
+	assert.Equal(t, 2, obj.count)
+	next.AssertExpectations(t)
+}
+
+func TestErrorLimitHandleExceeded(t *testing.T) {
+	next := &mockEncodingErrorHandler{}
+	obj := &errorLimit{limit: 2, count: 2, next: next}
+
+	result := obj.Handle(assert.AnError)
+
+	assert.Same(t, assert.AnError, result)
+	assert.Equal(t, 3, obj.count)
+	next.AssertExpectations(t)
+}
+
+func scanAll(s Scanner) (string, error) {
+	buf := &bytes.Buffer{}
+	for {
+		ch, err := s.Next()
+		if err != nil {
+			return buf.String(), err
+		} else if ch.Rune == EOF {
+			return buf.String(), nil
+		}
+		buf.WriteRune(ch.Rune)
+	}
+}
+
+func TestHandlersFileScannerReplace(t *testing.T) {
+	loc := FileLocation{File: "file", B: FilePos{L: 1, C: 1}, E: FilePos{L: 1, C: 1}}
+	obj := NewFileScanner(bytes.NewBufferString("a\xffb"), loc, EncodingError(ReplaceInvalid))
+
+	result, err := scanAll(obj)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "a�b", result)
+}
+
+func TestHandlersFileScannerSkip(t *testing.T) {
+	loc := FileLocation{File: "file", B: FilePos{L: 1, C: 1}, E: FilePos{L: 1, C: 1}}
+	obj := NewFileScanner(bytes.NewBufferString("a\xff\xfeb"), loc, EncodingError(SkipInvalid))
+
+	result, err := scanAll(obj)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ab", result)
+}
+
+func TestHandlersFileScannerCollect(t *testing.T) {
+	loc := FileLocation{File: "file", B: FilePos{L: 1, C: 1}, E: FilePos{L: 1, C: 1}}
+	handler := NewErrorCollector(SkipInvalid)
+	obj := NewFileScanner(bytes.NewBufferString("a\xff\xfeb\n\xff"), loc, EncodingError(handler))
+
+	result, err := scanAll(obj)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ab\n", result)
+	assert.Equal(t, []error{
+		LocationError(FileLocation{
+			File: "file",
+			B:    FilePos{L: 1, C: 2, O: 1, R: 1},
+			E:    FilePos{L: 1, C: 3, O: 2, R: 2},
+		}, ErrBadEncoding),
+		LocationError(FileLocation{
+			File: "file",
+			B:    FilePos{L: 1, C: 2, O: 1, R: 1},
+			E:    FilePos{L: 1, C: 3, O: 3, R: 3},
+		}, ErrBadEncoding),
+		LocationError(FileLocation{
+			File: "file",
+			B:    FilePos{L: 2, C: 1, O: 5, R: 5},
+			E:    FilePos{L: 2, C: 2, O: 6, R: 6},
+		}, ErrBadEncoding),
+	}, handler.Errors())
+}
+
+func TestHandlersFileScannerLimit(t *testing.T) {
+	loc := FileLocation{File: "file", B: FilePos{L: 1, C: 1}, E: FilePos{L: 1, C: 1}}
+	obj := NewFileScanner(bytes.NewBufferString("a\xffb\xffc\xffd"), loc, EncodingError(LimitErrors(2, nil)))
+
+	result, err := scanAll(obj)
+
+	assert.Equal(t, "a�b�c", result)
+	assert.True(t, errors.Is(err, ErrBadEncoding))
+	assert.Equal(t, FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 6, O: 5, R: 5},
+		E:    FilePos{L: 1, C: 7, O: 6, R: 6},
+	}, LocationOf(err))
+}
+
+func TestHandlersArgumentScannerCollect(t *testing.T) {
+	handler := NewErrorCollector(nil)
+	obj := NewArgumentScanner([]string{"a\xff", "\xffb"}, EncodingError(handler))
+
+	result, err := scanAll(obj)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "a� �b", result)
+	assert.Equal(t, []error{
+		LocationError(ArgLocation{
+			B: ArgPos{I: 1, C: 2},
+			E: ArgPos{I: 1, C: 3},
+		}, ErrBadEncoding),
+		LocationError(ArgLocation{
+			B: ArgPos{I: 2, C: 1},
+			E: ArgPos{I: 2, C: 2},
+		}, ErrBadEncoding),
+	}, handler.Errors())
+}
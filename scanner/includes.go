@@ -0,0 +1,189 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"bytes"
+)
+
+// IncludeLocation is an implementation of Location that wraps the
+// location of a character drawn from an included source.  It records
+// the include stack: the locations at which the source was included,
+// innermost first.  This allows errors to report where the source
+// was included from.
+type IncludeLocation struct {
+	Loc   Location   // The location within the included source
+	Stack []Location // The locations of the includes, innermost first
+}
+
+// String constructs a string representation of the location.
+func (l IncludeLocation) String() string {
+	buf := &bytes.Buffer{}
+	buf.WriteString(l.Loc.String())
+
+	// Add the include stack
+	for i, inc := range l.Stack {
+		if i == 0 {
+			buf.WriteString(" (included from ")
+		} else {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(inc.String())
+	}
+	if len(l.Stack) > 0 {
+		buf.WriteString(")")
+	}
+
+	return buf.String()
+}
+
+// Thru creates a new Location that ranges from the beginning of this
+// location to the beginning of another Location.
+func (l IncludeLocation) Thru(other Location) (Location, error) {
+	if o, ok := other.(IncludeLocation); ok {
+		other = o.Loc
+	}
+
+	loc, err := l.Loc.Thru(other)
+	if err != nil {
+		return nil, err
+	}
+
+	return IncludeLocation{
+		Loc:   loc,
+		Stack: l.Stack,
+	}, nil
+}
+
+// ThruEnd is similar to Thru, except that it creates a new Location
+// that ranges from the beginning of this location to the ending of
+// another location.
+func (l IncludeLocation) ThruEnd(other Location) (Location, error) {
+	if o, ok := other.(IncludeLocation); ok {
+		other = o.Loc
+	}
+
+	loc, err := l.Loc.ThruEnd(other)
+	if err != nil {
+		return nil, err
+	}
+
+	return IncludeLocation{
+		Loc:   loc,
+		Stack: l.Stack,
+	}, nil
+}
+
+// Incr increments the location by one character.  It is passed the
+// character (a rune) and the tabstop size (for handling tabs).  It
+// should return a new Location.
+func (l IncludeLocation) Incr(c rune, tabstop int) Location {
+	return IncludeLocation{
+		Loc:   l.Loc.Incr(c, tabstop),
+		Stack: l.Stack,
+	}
+}
+
+// include describes a single source on the include stack.
+type include struct {
+	src   Scanner    // The source scanner
+	stack []Location // The include stack for the source
+}
+
+// IncludeScanner is a scanner that allows other scanners to be
+// included at the current point in the character stream, typically in
+// response to an "include" directive.  Characters drawn from an
+// included scanner have their locations wrapped in an
+// IncludeLocation, as do the locations of errors it returns.  When an
+// included scanner returns EOF, the IncludeScanner resumes drawing
+// characters from the scanner that was active when it was included.
+type IncludeScanner struct {
+	srcs []include // The stack of sources, innermost last
+}
+
+// NewIncludeScanner constructs and returns an IncludeScanner that
+// draws characters from the specified scanner.
+func NewIncludeScanner(src Scanner) *IncludeScanner {
+	return &IncludeScanner{
+		srcs: []include{{src: src}},
+	}
+}
+
+// Include includes another scanner at the current point in the
+// character stream.  The location should be the location of the
+// include directive; it may be a location previously returned by the
+// IncludeScanner.  Note that characters that have already been read
+// from the IncludeScanner, such as characters buffered for lookahead,
+// are not affected.
+func (s *IncludeScanner) Include(src Scanner, at Location) {
+	// Construct the include stack for the source
+	var stack []Location
+	if il, ok := at.(IncludeLocation); ok {
+		stack = append([]Location{il.Loc}, il.Stack...)
+	} else {
+		stack = []Location{at}
+	}
+
+	s.srcs = append(s.srcs, include{
+		src:   src,
+		stack: stack,
+	})
+}
+
+// Depth returns the number of scanners that have been included and
+// have not yet been exhausted.
+func (s *IncludeScanner) Depth() int {
+	return len(s.srcs) - 1
+}
+
+// wrap is a helper that wraps a location with an include stack.
+func wrap(loc Location, stack []Location) Location {
+	if loc == nil || len(stack) == 0 {
+		return loc
+	}
+
+	return IncludeLocation{
+		Loc:   loc,
+		Stack: stack,
+	}
+}
+
+// Next returns the next character from the stream as a Char, which
+// will include the character's location.  If an error was
+// encountered, that will also be returned.
+func (s *IncludeScanner) Next() (Char, error) {
+	for {
+		top := s.srcs[len(s.srcs)-1]
+
+		// Get the next character from the current source
+		ch, err := top.src.Next()
+		if err != nil {
+			if loc := LocationOf(err); loc != nil {
+				err = relocateError(err, wrap(loc, top.stack))
+			}
+			ch.Loc = wrap(ch.Loc, top.stack)
+			return ch, err
+		}
+
+		// Resume the including source at EOF
+		if ch.Rune == EOF && len(s.srcs) > 1 {
+			s.srcs = s.srcs[:len(s.srcs)-1]
+			continue
+		}
+
+		ch.Loc = wrap(ch.Loc, top.stack)
+		return ch, nil
+	}
+}
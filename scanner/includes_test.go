@@ -0,0 +1,395 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncludeLocationImplementsLocation(t *testing.T) {
+	assert.Implements(t, (*Location)(nil), IncludeLocation{})
+}
+
+func TestIncludeLocationStringNoStack(t *testing.T) {
+	loc := &mockLocation{}
+	loc.On("String").Return("b.cfg:3:1")
+	obj := IncludeLocation{Loc: loc}
+
+	result := obj.String()
+
+	assert.Equal(t, "b.cfg:3:1", result)
+}
+
+func TestIncludeLocationStringStack(t *testing.T) {
+	loc := &mockLocation{}
+	loc.On("String").Return("c.cfg:3:1")
+	inc1 := &mockLocation{}
+	inc1.On("String").Return("b.cfg:2:1")
+	inc2 := &mockLocation{}
+	inc2.On("String").Return("a.cfg:10:5")
+	obj := IncludeLocation{
+		Loc:   loc,
+		Stack: []Location{inc1, inc2},
+	}
+
+	result := obj.String()
+
+	assert.Equal(t, "c.cfg:3:1 (included from b.cfg:2:1, a.cfg:10:5)", result)
+}
+
+func TestIncludeLocationThruBase(t *testing.T) {
+	stack := []Location{&mockLocation{}}
+	other := &mockLocation{}
+	thru := &mockLocation{}
+	loc := &mockLocation{}
+	loc.On("Thru", other).Return(thru, nil)
+	obj := IncludeLocation{Loc: loc, Stack: stack}
+
+	result, err := obj.Thru(IncludeLocation{Loc: other, Stack: stack})
+
+	assert.NoError(t, err)
+	assert.Equal(t, IncludeLocation{Loc: thru, Stack: stack}, result)
+	loc.AssertExpectations(t)
+}
+
+func TestIncludeLocationThruUnwrapped(t *testing.T) {
+	stack := []Location{&mockLocation{}}
+	other := &mockLocation{}
+	thru := &mockLocation{}
+	loc := &mockLocation{}
+	loc.On("Thru", other).Return(thru, nil)
+	obj := IncludeLocation{Loc: loc, Stack: stack}
+
+	result, err := obj.Thru(other)
+
+	assert.NoError(t, err)
+	assert.Equal(t, IncludeLocation{Loc: thru, Stack: stack}, result)
+	loc.AssertExpectations(t)
+}
+
+func TestIncludeLocationThruError(t *testing.T) {
+	other := &mockLocation{}
+	loc := &mockLocation{}
+	loc.On("Thru", other).Return(nil, ErrSplitLocation)
+	obj := IncludeLocation{Loc: loc}
+
+	result, err := obj.Thru(other)
+
+	assert.Same(t, ErrSplitLocation, err)
+	assert.Nil(t, result)
+	loc.AssertExpectations(t)
+}
+
+func TestIncludeLocationThruEndBase(t *testing.T) {
+	stack := []Location{&mockLocation{}}
+	other := &mockLocation{}
+	thru := &mockLocation{}
+	loc := &mockLocation{}
+	loc.On("ThruEnd", other).Return(thru, nil)
+	obj := IncludeLocation{Loc: loc, Stack: stack}
+
+	result, err := obj.ThruEnd(IncludeLocation{Loc: other, Stack: stack})
+
+	assert.NoError(t, err)
+	assert.Equal(t, IncludeLocation{Loc: thru, Stack: stack}, result)
+	loc.AssertExpectations(t)
+}
+
+func TestIncludeLocationThruEndError(t *testing.T) {
+	other := &mockLocation{}
+	loc := &mockLocation{}
+	loc.On("ThruEnd", other).Return(nil, ErrSplitLocation)
+	obj := IncludeLocation{Loc: loc}
+
+	result, err := obj.ThruEnd(other)
+
+	assert.Same(t, ErrSplitLocation, err)
+	assert.Nil(t, result)
+	loc.AssertExpectations(t)
+}
+
+func TestIncludeLocationIncr(t *testing.T) {
+	stack := []Location{&mockLocation{}}
+	next := &mockLocation{}
+	loc := &mockLocation{}
+	loc.On("Incr", 'c', 8).Return(next)
+	obj := IncludeLocation{Loc: loc, Stack: stack}
+
+	result := obj.Incr('c', 8)
+
+	assert.Equal(t, IncludeLocation{Loc: next, Stack: stack}, result)
+	loc.AssertExpectations(t)
+}
+
+func TestNewIncludeScanner(t *testing.T) {
+	src := &mockScanner{}
+
+	result := NewIncludeScanner(src)
+
+	assert.Equal(t, &IncludeScanner{
+		srcs: []include{{src: src}},
+	}, result)
+}
+
+func TestIncludeScannerIncludeBase(t *testing.T) {
+	src1 := &mockScanner{}
+	src2 := &mockScanner{}
+	at := &mockLocation{}
+	obj := &IncludeScanner{
+		srcs: []include{{src: src1}},
+	}
+
+	obj.Include(src2, at)
+
+	assert.Equal(t, []include{
+		{src: src1},
+		{src: src2, stack: []Location{at}},
+	}, obj.srcs)
+}
+
+func TestIncludeScannerIncludeNested(t *testing.T) {
+	src1 := &mockScanner{}
+	src2 := &mockScanner{}
+	src3 := &mockScanner{}
+	outer := &mockLocation{}
+	inner := &mockLocation{}
+	obj := &IncludeScanner{
+		srcs: []include{
+			{src: src1},
+			{src: src2, stack: []Location{outer}},
+		},
+	}
+
+	obj.Include(src3, IncludeLocation{Loc: inner, Stack: []Location{outer}})
+
+	assert.Equal(t, []include{
+		{src: src1},
+		{src: src2, stack: []Location{outer}},
+		{src: src3, stack: []Location{inner, outer}},
+	}, obj.srcs)
+}
+
+func TestIncludeScannerDepth(t *testing.T) {
+	obj := &IncludeScanner{
+		srcs: []include{{}, {}, {}},
+	}
+
+	result := obj.Depth()
+
+	assert.Equal(t, 2, result)
+}
+
+func TestWrapBase(t *testing.T) {
+	loc := &mockLocation{}
+	stack := []Location{&mockLocation{}}
+
+	result := wrap(loc, stack)
+
+	assert.Equal(t, IncludeLocation{Loc: loc, Stack: stack}, result)
+}
+
+func TestWrapNoStack(t *testing.T) {
+	loc := &mockLocation{}
+
+	result := wrap(loc, nil)
+
+	assert.Same(t, loc, result)
+}
+
+func TestWrapNoLocation(t *testing.T) {
+	result := wrap(nil, []Location{&mockLocation{}})
+
+	assert.Nil(t, result)
+}
+
+func TestIncludeScannerNextTopLevel(t *testing.T) {
+	loc := &mockLocation{}
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 'c', Loc: loc}, nil)
+	obj := &IncludeScanner{
+		srcs: []include{{src: src}},
+	}
+
+	result, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: 'c', Loc: loc}, result)
+	src.AssertExpectations(t)
+}
+
+func TestIncludeScannerNextTopLevelEOF(t *testing.T) {
+	loc := &mockLocation{}
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: EOF, Loc: loc}, nil)
+	obj := &IncludeScanner{
+		srcs: []include{{src: src}},
+	}
+
+	result, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: EOF, Loc: loc}, result)
+	assert.Len(t, obj.srcs, 1)
+	src.AssertExpectations(t)
+}
+
+func TestIncludeScannerNextIncluded(t *testing.T) {
+	at := &mockLocation{}
+	loc := &mockLocation{}
+	src1 := &mockScanner{}
+	src2 := &mockScanner{}
+	src2.On("Next").Return(Char{Rune: 'c', Loc: loc}, nil)
+	obj := &IncludeScanner{
+		srcs: []include{
+			{src: src1},
+			{src: src2, stack: []Location{at}},
+		},
+	}
+
+	result, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{
+		Rune: 'c',
+		Loc: IncludeLocation{
+			Loc:   loc,
+			Stack: []Location{at},
+		},
+	}, result)
+	src1.AssertExpectations(t)
+	src2.AssertExpectations(t)
+}
+
+func TestIncludeScannerNextIncludedEOF(t *testing.T) {
+	at := &mockLocation{}
+	loc := &mockLocation{}
+	src1 := &mockScanner{}
+	src1.On("Next").Return(Char{Rune: 'c', Loc: loc}, nil)
+	src2 := &mockScanner{}
+	src2.On("Next").Return(Char{Rune: EOF}, nil)
+	obj := &IncludeScanner{
+		srcs: []include{
+			{src: src1},
+			{src: src2, stack: []Location{at}},
+		},
+	}
+
+	result, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: 'c', Loc: loc}, result)
+	assert.Len(t, obj.srcs, 1)
+	src1.AssertExpectations(t)
+	src2.AssertExpectations(t)
+}
+
+func TestIncludeScannerNextIncludedError(t *testing.T) {
+	at := &mockLocation{}
+	loc := &mockLocation{}
+	src1 := &mockScanner{}
+	src2 := &mockScanner{}
+	src2.On("Next").Return(Char{Rune: EOF, Loc: loc}, LocationError(loc, assert.AnError))
+	obj := &IncludeScanner{
+		srcs: []include{
+			{src: src1},
+			{src: src2, stack: []Location{at}},
+		},
+	}
+
+	result, err := obj.Next()
+
+	assert.Equal(t, LocationError(IncludeLocation{
+		Loc:   loc,
+		Stack: []Location{at},
+	}, assert.AnError), err)
+	assert.Equal(t, Char{
+		Rune: EOF,
+		Loc: IncludeLocation{
+			Loc:   loc,
+			Stack: []Location{at},
+		},
+	}, result)
+	assert.Len(t, obj.srcs, 2)
+	src1.AssertExpectations(t)
+	src2.AssertExpectations(t)
+}
+
+func TestIncludeScannerIntegration(t *testing.T) {
+	newFile := func(file, text string) Scanner {
+		return NewFileScanner(bytes.NewBufferString(text), FileLocation{
+			File: file,
+			B:    FilePos{L: 1, C: 1},
+			E:    FilePos{L: 1, C: 1},
+		})
+	}
+	obj := NewIncludeScanner(newFile("a.cfg", "a@b"))
+	result := &bytes.Buffer{}
+	var last Char
+
+	for {
+		ch, err := obj.Next()
+		assert.NoError(t, err)
+		if ch.Rune == EOF {
+			break
+		}
+		switch ch.Rune {
+		case '@':
+			obj.Include(newFile("b.cfg", "x\ny#z"), ch.Loc)
+		case '#':
+			obj.Include(newFile("c.cfg", "q"), ch.Loc)
+		default:
+			result.WriteRune(ch.Rune)
+			last = ch
+		}
+		if ch.Rune == 'q' {
+			assert.Equal(t, "c.cfg:1:1 (included from b.cfg:2:2, a.cfg:1:2)", ch.Loc.String())
+		}
+	}
+
+	assert.Equal(t, "ax\nyqzb", result.String())
+	assert.Equal(t, "a.cfg:1:3", last.Loc.String())
+}
+
+func TestIncludeScannerNextIncludedWrappedError(t *testing.T) {
+	at := FileLocation{File: "a.cfg", B: FilePos{L: 10, C: 5}, E: FilePos{L: 10, C: 6}}
+	loc := FileLocation{File: "b.cfg", B: FilePos{L: 3, C: 1}, E: FilePos{L: 3, C: 2}}
+	src1 := &mockScanner{}
+	src2 := &mockScanner{}
+	inner := fmt.Errorf("reading: %w", LocationError(loc, assert.AnError))
+	src2.On("Next").Return(Char{Rune: EOF, Loc: loc}, inner)
+	obj := &IncludeScanner{
+		srcs: []include{
+			{src: src1},
+			{src: src2, stack: []Location{at}},
+		},
+	}
+
+	_, err := obj.Next()
+
+	expected := IncludeLocation{
+		Loc:   loc,
+		Stack: []Location{at},
+	}
+	assert.Equal(t, expected, LocationOf(err))
+	assert.Equal(t, fmt.Sprintf("%s: reading: %s", expected, assert.AnError), err.Error())
+	assert.True(t, errors.Is(err, assert.AnError))
+	src1.AssertExpectations(t)
+	src2.AssertExpectations(t)
+}
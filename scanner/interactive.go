@@ -0,0 +1,168 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import "io"
+
+// MoreInput is an interface for a source of additional input for an
+// InteractiveScanner.  This is typically implemented by a REPL, which
+// would display a continuation prompt and read another line from the
+// user.
+type MoreInput interface {
+	// More is called when the scanner has consumed all of the
+	// input it has been fed and needs more to continue.  It
+	// returns the additional input, which should include the line
+	// ending.  It should return io.EOF if no more input is
+	// available; any other error is reported by the scanner.
+	More() (string, error)
+}
+
+// IResumableScanner is an interface for a scanner whose EOF need not
+// be final: after returning EOF, it may return more characters once
+// more input has been provided.  Consumers such as the lexer's
+// BackTracker use the Ready method to determine whether to continue
+// reading from the scanner.
+type IResumableScanner interface {
+	Scanner
+
+	// Ready reports whether the scanner may have more characters
+	// to return.  It returns false once the scanner has returned
+	// EOF, until more input has been provided.
+	Ready() bool
+}
+
+// MoreInputFunc is an implementation of MoreInput that wraps a
+// function.
+type MoreInputFunc func() (string, error)
+
+// More is called when the scanner has consumed all of the input it
+// has been fed and needs more to continue.  It returns the additional
+// input, which should include the line ending.  It should return
+// io.EOF if no more input is available; any other error is reported
+// by the scanner.
+func (f MoreInputFunc) More() (string, error) {
+	return f()
+}
+
+// input is an implementation of io.Reader that returns the input fed
+// to an InteractiveScanner, requesting more from the MoreInput when
+// it runs out.
+type input struct {
+	more  MoreInput // Source of additional input
+	queue []string  // Input that has been fed
+	cur   string    // Unread portion of the current input
+}
+
+// Read reads the input into p.  If all the input has been read, it
+// requests more from the MoreInput; if there is none, it returns
+// io.EOF.
+func (in *input) Read(p []byte) (int, error) {
+	for in.cur == "" {
+		if len(in.queue) > 0 {
+			in.cur = in.queue[0]
+			in.queue = in.queue[1:]
+			continue
+		}
+
+		// Need more input
+		if in.more == nil {
+			return 0, io.EOF
+		}
+		text, err := in.more.More()
+		if err != nil {
+			return 0, err
+		}
+		in.cur = text
+	}
+
+	n := copy(p, in.cur)
+	in.cur = in.cur[n:]
+	return n, nil
+}
+
+// InteractiveScanner is an implementation of Scanner for interactive
+// use, such as in a REPL.  It scans the input that has been fed to it
+// with Feed; when that input is exhausted, it requests more from its
+// MoreInput, if it has one, and otherwise reports EOF.  That EOF is
+// not final: once more input is fed to the scanner, it resumes
+// scanning where it left off.  Since more input is only requested
+// when the scanner needs another character, a Lexer or Parser reading
+// from the InteractiveScanner will pause where it is when the input
+// ends in an incomplete expression, such as one with unbalanced
+// parentheses, and resume with the additional input without scanning
+// the earlier input again.
+type InteractiveScanner struct {
+	s   *FileScanner // The scanner for the input
+	in  *input       // The input to scan
+	eof bool         // Whether the input has been exhausted
+}
+
+// NewInteractiveScanner constructs a new InteractiveScanner.  The
+// location is the location at which the input begins, as for
+// NewFileScanner.  The more parameter may be nil, in which case the
+// scanner reports EOF once the input fed to it has been exhausted,
+// and resumes when more input is fed to it.
+func NewInteractiveScanner(loc Location, more MoreInput, options ...FileOption) *InteractiveScanner {
+	in := &input{
+		more:  more,
+		queue: []string{},
+	}
+
+	return &InteractiveScanner{
+		s:  NewFileScanner(in, loc, options...),
+		in: in,
+	}
+}
+
+// Feed adds input to the scanner.  The input will be scanned after
+// any input previously fed to the scanner, and before any requested
+// from the MoreInput.  If the scanner has reported EOF, it resumes
+// scanning with the new input, unless it was stopped by an error.
+func (is *InteractiveScanner) Feed(text ...string) {
+	is.in.queue = append(is.in.queue, text...)
+	if is.eof && is.s.resume(is.in) {
+		is.eof = false
+	}
+}
+
+// Next returns the next character from the stream as a Char, which
+// will include the character's location.  If an error was
+// encountered, that will also be returned.
+func (is *InteractiveScanner) Next() (Char, error) {
+	ch, err := is.s.Next()
+	if ch.Rune == EOF {
+		is.eof = true
+	}
+
+	return ch, err
+}
+
+// Ready reports whether the scanner may have more characters to
+// return.  It returns false once the scanner has returned EOF, until
+// more input is fed to it.
+func (is *InteractiveScanner) Ready() bool {
+	return !is.eof
+}
+
+// Discard discards all the input that has been fed to the scanner
+// but not yet scanned, including the remainder of the current line.
+// This is typically used by a REPL to recover from a syntax error.
+// Note that characters already read from the scanner, such as those
+// saved by a lexer for backtracking, are not affected.
+func (is *InteractiveScanner) Discard() {
+	is.in.queue = []string{}
+	is.in.cur = ""
+	is.s.reset(is.in)
+}
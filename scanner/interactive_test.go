@@ -0,0 +1,338 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockMoreInput struct {
+	mock.Mock
+}
+
+func (m *mockMoreInput) More() (string, error) {
+	args := m.MethodCalled("More")
+
+	return args.String(0), args.Error(1)
+}
+
+func TestMoreInputFuncImplementsMoreInput(t *testing.T) {
+	assert.Implements(t, (*MoreInput)(nil), MoreInputFunc(nil))
+}
+
+func TestMoreInputFuncMore(t *testing.T) {
+	obj := MoreInputFunc(func() (string, error) {
+		return "text", assert.AnError
+	})
+
+	result, err := obj.More()
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, "text", result)
+}
+
+func TestInputImplementsReader(t *testing.T) {
+	assert.Implements(t, (*io.Reader)(nil), &input{})
+}
+
+func TestInputReadCurrent(t *testing.T) {
+	more := &mockMoreInput{}
+	obj := &input{
+		more:  more,
+		queue: []string{"queued"},
+		cur:   "text",
+	}
+	p := make([]byte, 10)
+
+	n, err := obj.Read(p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "text", string(p[:n]))
+	assert.Equal(t, "", obj.cur)
+	assert.Equal(t, []string{"queued"}, obj.queue)
+	more.AssertExpectations(t)
+}
+
+func TestInputReadShort(t *testing.T) {
+	obj := &input{cur: "text"}
+	p := make([]byte, 3)
+
+	n, err := obj.Read(p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "tex", string(p[:n]))
+	assert.Equal(t, "t", obj.cur)
+}
+
+func TestInputReadQueued(t *testing.T) {
+	more := &mockMoreInput{}
+	obj := &input{
+		more:  more,
+		queue: []string{"", "text", "other"},
+	}
+	p := make([]byte, 10)
+
+	n, err := obj.Read(p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "text", string(p[:n]))
+	assert.Equal(t, []string{"other"}, obj.queue)
+	more.AssertExpectations(t)
+}
+
+func TestInputReadMore(t *testing.T) {
+	more := &mockMoreInput{}
+	more.On("More").Return("text", nil)
+	obj := &input{
+		more:  more,
+		queue: []string{},
+	}
+	p := make([]byte, 10)
+
+	n, err := obj.Read(p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "text", string(p[:n]))
+	more.AssertExpectations(t)
+}
+
+func TestInputReadMoreError(t *testing.T) {
+	more := &mockMoreInput{}
+	more.On("More").Return("", assert.AnError)
+	obj := &input{
+		more:  more,
+		queue: []string{},
+	}
+	p := make([]byte, 10)
+
+	n, err := obj.Read(p)
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, 0, n)
+	more.AssertExpectations(t)
+}
+
+func TestInputReadNoMore(t *testing.T) {
+	obj := &input{
+		queue: []string{},
+	}
+	p := make([]byte, 10)
+
+	n, err := obj.Read(p)
+
+	assert.Same(t, io.EOF, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestInteractiveScannerImplementsIResumableScanner(t *testing.T) {
+	assert.Implements(t, (*IResumableScanner)(nil), &InteractiveScanner{})
+}
+
+func TestNewInteractiveScanner(t *testing.T) {
+	loc := &mockLocation{}
+	more := &mockMoreInput{}
+
+	result := NewInteractiveScanner(loc, more, TabStop(4))
+
+	assert.Equal(t, &input{more: more, queue: []string{}}, result.in)
+	assert.Same(t, result.in, result.s.src)
+	assert.Same(t, loc, result.s.loc)
+	assert.Equal(t, 4, result.s.ts)
+}
+
+func TestInteractiveScannerFeed(t *testing.T) {
+	obj := &InteractiveScanner{
+		in: &input{queue: []string{"a"}},
+	}
+
+	obj.Feed("b", "c")
+
+	assert.Equal(t, []string{"a", "b", "c"}, obj.in.queue)
+}
+
+func TestInteractiveScannerFeedResume(t *testing.T) {
+	obj := &InteractiveScanner{
+		s:   &FileScanner{saved: EOF},
+		in:  &input{},
+		eof: true,
+	}
+
+	obj.Feed("a")
+
+	assert.False(t, obj.eof)
+	assert.Same(t, obj.in, obj.s.src)
+	assert.Equal(t, sentinel, obj.s.saved)
+}
+
+func TestInteractiveScannerFeedStopped(t *testing.T) {
+	obj := &InteractiveScanner{
+		s:   &FileScanner{err: assert.AnError},
+		in:  &input{},
+		eof: true,
+	}
+
+	obj.Feed("a")
+
+	assert.True(t, obj.eof)
+	assert.Nil(t, obj.s.src)
+}
+
+func TestInteractiveScannerReady(t *testing.T) {
+	obj := &InteractiveScanner{}
+
+	assert.True(t, obj.Ready())
+	obj.eof = true
+	assert.False(t, obj.Ready())
+}
+
+func TestInteractiveScannerDiscard(t *testing.T) {
+	obj := &InteractiveScanner{
+		s: &FileScanner{
+			pos:   1,
+			end:   3,
+			saved: 'c',
+			ext:   Extent{Bytes: 1, Runes: 1},
+			sext:  Extent{Bytes: 1, Runes: 1},
+			pend:  &Char{Rune: 'c'},
+			perr:  assert.AnError,
+			crLoc: &mockLocation{},
+		},
+		in: &input{
+			queue: []string{"a", "b"},
+			cur:   "text",
+		},
+	}
+
+	obj.Discard()
+
+	assert.Equal(t, &FileScanner{
+		src:   obj.in,
+		pos:   3,
+		end:   3,
+		saved: sentinel,
+	}, obj.s)
+	assert.Equal(t, &input{queue: []string{}}, obj.in)
+}
+
+func TestInteractiveScannerNext(t *testing.T) {
+	lines := []string{"b\n", ")\n"}
+	calls := 0
+	more := MoreInputFunc(func() (string, error) {
+		if calls >= len(lines) {
+			return "", io.EOF
+		}
+		calls++
+		return lines[calls-1], nil
+	})
+	obj := NewInteractiveScanner(FileLocation{
+		File: "<stdin>",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}, more)
+	obj.Feed("(a\n")
+
+	runes := []rune{}
+	for i := 0; i < 3; i++ {
+		ch, err := obj.Next()
+		require.NoError(t, err)
+		runes = append(runes, ch.Rune)
+	}
+	assert.Equal(t, "(a\n", string(runes))
+	assert.Equal(t, 0, calls)
+
+	ch, err := obj.Next()
+	require.NoError(t, err)
+	assert.Equal(t, 'b', ch.Rune)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, FileLocation{
+		File: "<stdin>",
+		B:    FilePos{L: 2, C: 1, O: 3, R: 3},
+		E:    FilePos{L: 2, C: 2, O: 4, R: 4},
+	}, ch.Loc)
+
+	result, err := scanAll(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, "\n)\n", result)
+	assert.Equal(t, 2, calls)
+}
+
+func TestInteractiveScannerNextError(t *testing.T) {
+	more := &mockMoreInput{}
+	more.On("More").Return("", assert.AnError)
+	obj := NewInteractiveScanner(FileLocation{
+		File: "<stdin>",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}, more)
+	obj.Feed("a")
+
+	result, err := scanAll(obj)
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, "a", result)
+	more.AssertExpectations(t)
+}
+
+func TestInteractiveScannerNextResume(t *testing.T) {
+	obj := NewInteractiveScanner(FileLocation{
+		File: "x",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}, nil)
+	obj.Feed("a\n")
+
+	result, err := scanAll(obj)
+	require.NoError(t, err)
+	assert.Equal(t, "a\n", result)
+	assert.False(t, obj.Ready())
+
+	obj.Feed("b\n")
+
+	assert.True(t, obj.Ready())
+	ch, err := obj.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: 'b', Loc: FileLocation{
+		File: "x",
+		B:    FilePos{L: 2, C: 1, O: 2, R: 2},
+		E:    FilePos{L: 2, C: 2, O: 3, R: 3},
+	}}, ch)
+	result, err = scanAll(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, "\n", result)
+}
+
+func TestInteractiveScannerNextResumeLineEnding(t *testing.T) {
+	obj := NewInteractiveScanner(FileLocation{
+		File: "x",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}, nil, LineEndings(DOSLineStyle))
+	obj.Feed("a\r")
+
+	result, err := scanAll(obj)
+	require.NoError(t, err)
+	assert.Equal(t, "a ", result)
+
+	obj.Feed("b")
+
+	result, err = scanAll(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", result)
+}
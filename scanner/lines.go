@@ -36,6 +36,20 @@ type LineStyle interface {
 	Handle(chs []rune) (LineDis, LineStyle)
 }
 
+// TerminatorLineStyle is an optional interface for LineStyle
+// implementations that recognize line terminators other than carriage
+// returns and newlines.  The FileScanner passes characters for which
+// IsTerminator returns true to the Handle method, in addition to
+// carriage returns and newlines.
+type TerminatorLineStyle interface {
+	LineStyle
+
+	// IsTerminator checks to see if a character begins a line
+	// ending sequence.  It need not return true for carriage
+	// returns or newlines.
+	IsTerminator(ch rune) bool
+}
+
 // unixLineStyle is a style for handling the case of bare newline line
 // endings, also known as UNIX line endings.
 type unixLineStyle struct{}
@@ -162,3 +176,90 @@ func (ls *noLineStyle) Handle(chs []rune) (LineDis, LineStyle) {
 // the source should be recognized.  All carriage returns and newlines
 // will be substituted with spaces.
 var NoLineStyle = &noLineStyle{}
+
+// Unicode line terminators other than carriage return and newline.
+const (
+	NextLine           rune = '\u0085' // Next line (NEL)
+	LineSeparator      rune = '\u2028' // Line separator
+	ParagraphSeparator rune = '\u2029' // Paragraph separator
+)
+
+// unicodeLineStyle is a style for handling all the line terminators
+// recognized by Unicode, as used by languages such as JavaScript.
+type unicodeLineStyle struct{}
+
+// IsTerminator checks to see if a character begins a line ending
+// sequence.
+func (ls *unicodeLineStyle) IsTerminator(ch rune) bool {
+	return ch == NextLine || ch == LineSeparator || ch == ParagraphSeparator
+}
+
+// Handle checks to see if a line ending sequence has been
+// encountered.  It returns a LineDis value, which indicates the
+// disposition of the character; and a LineStyle object to use next
+// time around.
+func (ls *unicodeLineStyle) Handle(chs []rune) (LineDis, LineStyle) {
+	// Anything other than a carriage return is a newline
+	if chs[0] != '\r' {
+		return LineDisNewline, ls
+	}
+
+	// Carriage return; need to know what's next
+	if len(chs) <= 1 {
+		return LineDisMore, ls
+	}
+
+	// Line ending sequence
+	if chs[1] == '\n' {
+		return LineDisNewline, ls
+	}
+
+	// Bare carriage return
+	return LineDisNewlineSave, ls
+}
+
+// UnicodeLineStyle is a style for handling all the line terminators
+// recognized by Unicode: newlines, carriage returns, carriage return
+// and newline pairs, next line (U+0085), line separator (U+2028), and
+// paragraph separator (U+2029).  Each is converted into a single
+// newline.
+var UnicodeLineStyle = &unicodeLineStyle{}
+
+// isTerminator is a helper for scanners that checks to see if a
+// character begins a line ending sequence under a line style.
+func isTerminator(ls LineStyle, ch rune) bool {
+	if ch == '\r' || ch == '\n' {
+		return true
+	} else if tls, ok := ls.(TerminatorLineStyle); ok {
+		return tls.IsTerminator(ch)
+	}
+
+	return false
+}
+
+// lineEnding is a helper for scanners that applies a line style to
+// the line ending sequence beginning with the designated character.
+// The next function is called to read each additional character the
+// line style needs to see.  It returns the character to report in
+// place of the sequence, the sequence, whether the last character of
+// the sequence must be returned to the input, and the line style to
+// use next time around.
+func lineEnding(ls LineStyle, ch rune, next func() rune) (rune, []rune, bool, LineStyle) {
+	seq := []rune{ch}
+	for {
+		dis, nls := ls.Handle(seq)
+		switch dis {
+		case LineDisNewline:
+			return '\n', seq, false, nls
+		case LineDisNewlineSave:
+			return '\n', seq, true, nls
+		case LineDisSpace:
+			return ' ', seq, len(seq) > 1, nls
+		case LineDisMore:
+			seq = append(seq, next())
+			continue
+		}
+
+		return ch, seq, false, nls
+	}
+}
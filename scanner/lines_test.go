@@ -166,3 +166,142 @@ func TestNoLineStyleHandleNL(t *testing.T) {
 	assert.Equal(t, LineDisSpace, dis)
 	assert.Same(t, NoLineStyle, next)
 }
+
+func TestUnicodeLineStyleImplementsTerminatorLineStyle(t *testing.T) {
+	assert.Implements(t, (*TerminatorLineStyle)(nil), UnicodeLineStyle)
+}
+
+func TestUnicodeLineStyleIsTerminator(t *testing.T) {
+	assert.True(t, UnicodeLineStyle.IsTerminator(NextLine))
+	assert.True(t, UnicodeLineStyle.IsTerminator(LineSeparator))
+	assert.True(t, UnicodeLineStyle.IsTerminator(ParagraphSeparator))
+	assert.False(t, UnicodeLineStyle.IsTerminator('a'))
+	assert.False(t, UnicodeLineStyle.IsTerminator('\v'))
+}
+
+func TestUnicodeLineStyleHandleCR(t *testing.T) {
+	dis, next := UnicodeLineStyle.Handle([]rune{'\r'})
+
+	assert.Equal(t, LineDisMore, dis)
+	assert.Same(t, UnicodeLineStyle, next)
+}
+
+func TestUnicodeLineStyleHandleNL(t *testing.T) {
+	dis, next := UnicodeLineStyle.Handle([]rune{'\n'})
+
+	assert.Equal(t, LineDisNewline, dis)
+	assert.Same(t, UnicodeLineStyle, next)
+}
+
+func TestUnicodeLineStyleHandleNEL(t *testing.T) {
+	dis, next := UnicodeLineStyle.Handle([]rune{NextLine})
+
+	assert.Equal(t, LineDisNewline, dis)
+	assert.Same(t, UnicodeLineStyle, next)
+}
+
+func TestUnicodeLineStyleHandleLS(t *testing.T) {
+	dis, next := UnicodeLineStyle.Handle([]rune{LineSeparator})
+
+	assert.Equal(t, LineDisNewline, dis)
+	assert.Same(t, UnicodeLineStyle, next)
+}
+
+func TestUnicodeLineStyleHandlePS(t *testing.T) {
+	dis, next := UnicodeLineStyle.Handle([]rune{ParagraphSeparator})
+
+	assert.Equal(t, LineDisNewline, dis)
+	assert.Same(t, UnicodeLineStyle, next)
+}
+
+func TestUnicodeLineStyleHandleCRNL(t *testing.T) {
+	dis, next := UnicodeLineStyle.Handle([]rune{'\r', '\n'})
+
+	assert.Equal(t, LineDisNewline, dis)
+	assert.Same(t, UnicodeLineStyle, next)
+}
+
+func TestUnicodeLineStyleHandleCRCR(t *testing.T) {
+	dis, next := UnicodeLineStyle.Handle([]rune{'\r', '\r'})
+
+	assert.Equal(t, LineDisNewlineSave, dis)
+	assert.Same(t, UnicodeLineStyle, next)
+}
+
+func TestUnicodeLineStyleHandleCREOF(t *testing.T) {
+	dis, next := UnicodeLineStyle.Handle([]rune{'\r', EOF})
+
+	assert.Equal(t, LineDisNewlineSave, dis)
+	assert.Same(t, UnicodeLineStyle, next)
+}
+
+func TestIsTerminatorNL(t *testing.T) {
+	assert.True(t, isTerminator(UNIXLineStyle, '\n'))
+}
+
+func TestIsTerminatorCR(t *testing.T) {
+	assert.True(t, isTerminator(UNIXLineStyle, '\r'))
+}
+
+func TestIsTerminatorOther(t *testing.T) {
+	assert.False(t, isTerminator(UNIXLineStyle, ' '))
+}
+
+func TestIsTerminatorTerminatorLineStyle(t *testing.T) {
+	assert.True(t, isTerminator(UnicodeLineStyle, ' '))
+}
+
+func TestLineEndingNewline(t *testing.T) {
+	ch, seq, unread, ls := lineEnding(UNIXLineStyle, '\n', nil)
+
+	assert.Equal(t, '\n', ch)
+	assert.Equal(t, []rune{'\n'}, seq)
+	assert.False(t, unread)
+	assert.Same(t, UNIXLineStyle, ls)
+}
+
+func TestLineEndingMore(t *testing.T) {
+	ch, seq, unread, ls := lineEnding(UnknownLineStyle, '\r', func() rune {
+		return '\n'
+	})
+
+	assert.Equal(t, '\n', ch)
+	assert.Equal(t, []rune{'\r', '\n'}, seq)
+	assert.False(t, unread)
+	assert.Same(t, DOSLineStyle, ls)
+}
+
+func TestLineEndingNewlineSave(t *testing.T) {
+	ch, seq, unread, ls := lineEnding(UnknownLineStyle, '\r', func() rune {
+		return 'a'
+	})
+
+	assert.Equal(t, '\n', ch)
+	assert.Equal(t, []rune{'\r', 'a'}, seq)
+	assert.True(t, unread)
+	assert.Same(t, MacLineStyle, ls)
+}
+
+func TestLineEndingSpace(t *testing.T) {
+	ch, seq, unread, ls := lineEnding(DOSLineStyle, '\r', func() rune {
+		return 'a'
+	})
+
+	assert.Equal(t, ' ', ch)
+	assert.Equal(t, []rune{'\r', 'a'}, seq)
+	assert.True(t, unread)
+	assert.Same(t, DOSLineStyle, ls)
+}
+
+func TestLineEndingPass(t *testing.T) {
+	style := &mockLineStyle{}
+	style.On("Handle", []rune{'\r'}).Return(LineDis(42), UNIXLineStyle)
+
+	ch, seq, unread, ls := lineEnding(style, '\r', nil)
+
+	assert.Equal(t, '\r', ch)
+	assert.Equal(t, []rune{'\r'}, seq)
+	assert.False(t, unread)
+	assert.Same(t, UNIXLineStyle, ls)
+	style.AssertExpectations(t)
+}
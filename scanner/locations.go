@@ -14,6 +14,11 @@
 
 package scanner
 
+import (
+	"encoding/json"
+	"strings"
+)
+
 // Location is an interface for location data.  Each token and node
 // should have attached location data that reports its location.  This
 // aids in finding the location of errors.
@@ -35,3 +40,146 @@ type Location interface {
 	// handling tabs).  It should return a new Location.
 	Incr(c rune, tabstop int) Location
 }
+
+// Extent describes the source text consumed to produce a single
+// character, along with the number of columns the character
+// occupies.  A scanner may consume more than one rune of source to
+// produce a single character; for instance, a carriage return and
+// newline pair is converted into a single newline.
+type Extent struct {
+	Bytes int // Number of bytes of source consumed
+	Runes int // Number of runes of source consumed
+	Cols  int // Number of columns the character occupies
+}
+
+// OffsetLocation is an optional interface for Location
+// implementations that track offsets into the source.  Scanners that
+// know the extent of the source consumed by a character will call
+// IncrExtent in preference to Incr.
+type OffsetLocation interface {
+	Location
+
+	// IncrExtent is similar to Incr, except that it is also
+	// passed the extent of the source consumed by the character.
+	IncrExtent(c rune, tabstop int, ext Extent) Location
+}
+
+// OrderedLocation is an optional interface for Location
+// implementations that can be compared with other locations.  The
+// methods of OrderedLocation report false, or return an error, when
+// passed a location that they cannot be compared with, such as a
+// location of a different type.
+type OrderedLocation interface {
+	Location
+
+	// Compare compares this location with another location.  It
+	// returns a negative number if this location sorts before the
+	// other location, a positive number if it sorts after, and 0
+	// if the locations are the same.  Locations are ordered by
+	// their beginnings, then by their endings.  If the locations
+	// cannot be compared, ErrSplitLocation is returned.
+	Compare(other Location) (int, error)
+
+	// Before returns true if this location ends at or before the
+	// beginning of another location.
+	Before(other Location) bool
+
+	// Contains returns true if another location lies entirely
+	// within this location.
+	Contains(other Location) bool
+
+	// Overlaps returns true if this location and another location
+	// have any characters in common.  An empty location overlaps
+	// another location if it lies within it.
+	Overlaps(other Location) bool
+
+	// Union creates a new Location that ranges from the earlier
+	// of the beginnings of this location and another location to
+	// the later of their endings.
+	Union(other Location) (Location, error)
+}
+
+// compareInts is a helper that compares two integers, returning -1,
+// 0, or 1.
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// Compare compares two locations of any type, for use in sorting
+// them.  It returns a negative number if a sorts before b, a positive
+// number if a sorts after b, and 0 if they sort the same.  Locations
+// implementing OrderedLocation are compared using the Compare method;
+// otherwise, locations are compared by their string representations.
+// A nil location sorts before all other locations.
+func Compare(a, b Location) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	// Try comparing the locations
+	if ol, ok := a.(OrderedLocation); ok {
+		if result, err := ol.Compare(b); err == nil {
+			return result
+		}
+	}
+	if ol, ok := b.(OrderedLocation); ok {
+		if result, err := ol.Compare(a); err == nil {
+			return -result
+		}
+	}
+
+	return strings.Compare(a.String(), b.String())
+}
+
+// Location type names used in the JSON encoding of locations.
+const (
+	FileLocationType = "file" // Type name of FileLocation
+	ArgLocationType  = "arg"  // Type name of ArgLocation
+)
+
+// locationType is used to decode the type of a location from its JSON
+// encoding.
+type locationType struct {
+	Type string `json:"type"`
+}
+
+// UnmarshalLocation decodes a location from its JSON encoding, as
+// produced by json.Marshal.  The concrete type of the location is
+// determined from the "type" field of the encoding.  If the type is
+// not recognized, ErrLocationType is returned.
+func UnmarshalLocation(data []byte) (Location, error) {
+	lt := locationType{}
+	if err := json.Unmarshal(data, &lt); err != nil {
+		return nil, err
+	}
+
+	switch lt.Type {
+	case FileLocationType:
+		loc := FileLocation{}
+		if err := json.Unmarshal(data, &loc); err != nil {
+			return nil, err
+		}
+		return loc, nil
+
+	case ArgLocationType:
+		loc := ArgLocation{}
+		if err := json.Unmarshal(data, &loc); err != nil {
+			return nil, err
+		}
+		return loc, nil
+	}
+
+	return nil, ErrLocationType
+}
@@ -14,7 +14,12 @@
 
 package scanner
 
-import "github.com/stretchr/testify/mock"
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
 
 type mockLocation struct {
 	mock.Mock
@@ -55,3 +60,99 @@ func (m *mockLocation) Incr(c rune, tabstop int) Location {
 
 	return nil
 }
+
+func TestCompareInts(t *testing.T) {
+	assert.Equal(t, -1, compareInts(1, 2))
+	assert.Equal(t, 0, compareInts(2, 2))
+	assert.Equal(t, 1, compareInts(3, 2))
+}
+
+func TestCompareNil(t *testing.T) {
+	loc := &mockLocation{}
+
+	assert.Equal(t, 0, Compare(nil, nil))
+	assert.Equal(t, -1, Compare(nil, loc))
+	assert.Equal(t, 1, Compare(loc, nil))
+}
+
+func TestCompareOrdered(t *testing.T) {
+	a := FileLocation{File: "file", B: FilePos{L: 1, C: 5}, E: FilePos{L: 1, C: 6}}
+	b := FileLocation{File: "file", B: FilePos{L: 2, C: 1}, E: FilePos{L: 2, C: 2}}
+
+	assert.Equal(t, -1, Compare(a, b))
+	assert.Equal(t, 1, Compare(b, a))
+	assert.Equal(t, 0, Compare(a, a))
+}
+
+func TestCompareOrderedOther(t *testing.T) {
+	a := &mockLocation{}
+	b := FileLocation{File: "file", B: FilePos{L: 2, C: 1}, E: FilePos{L: 2, C: 2}}
+	a.On("String").Return("a")
+
+	result := Compare(a, b)
+
+	assert.Equal(t, -1, result)
+	a.AssertExpectations(t)
+}
+
+func TestCompareString(t *testing.T) {
+	a := &mockLocation{}
+	b := &mockLocation{}
+	a.On("String").Return("b")
+	b.On("String").Return("a")
+
+	result := Compare(a, b)
+
+	assert.Equal(t, 1, result)
+	a.AssertExpectations(t)
+	b.AssertExpectations(t)
+}
+
+func TestUnmarshalLocationFile(t *testing.T) {
+	result, err := UnmarshalLocation([]byte(`{"type":"file","file":"file","begin":{"line":1,"col":2,"offset":1,"rune":1},"end":{"line":1,"col":3,"offset":2,"rune":2}}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 2, O: 1, R: 1},
+		E:    FilePos{L: 1, C: 3, O: 2, R: 2},
+	}, result)
+}
+
+func TestUnmarshalLocationArg(t *testing.T) {
+	result, err := UnmarshalLocation([]byte(`{"type":"arg","begin":{"arg":1,"char":2},"end":{"arg":1,"char":3}}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, ArgLocation{
+		B: ArgPos{I: 1, C: 2},
+		E: ArgPos{I: 1, C: 3},
+	}, result)
+}
+
+func TestUnmarshalLocationUnknown(t *testing.T) {
+	result, err := UnmarshalLocation([]byte(`{"type":"other"}`))
+
+	assert.Same(t, ErrLocationType, err)
+	assert.Nil(t, result)
+}
+
+func TestUnmarshalLocationBadJSON(t *testing.T) {
+	result, err := UnmarshalLocation([]byte(`{`))
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
+
+func TestUnmarshalLocationBadFile(t *testing.T) {
+	result, err := UnmarshalLocation([]byte(`{"type":"file","file":1}`))
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
+
+func TestUnmarshalLocationBadArg(t *testing.T) {
+	result, err := UnmarshalLocation([]byte(`{"type":"arg","begin":1}`))
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
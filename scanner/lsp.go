@@ -0,0 +1,180 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import "unicode/utf8"
+
+// LSPPosition is a position in a text document, as defined by the
+// Language Server Protocol.  Both the line and the character are
+// 0-indexed, and the character is measured in UTF-16 code units.
+type LSPPosition struct {
+	Line      int `json:"line"`      // The line of the position
+	Character int `json:"character"` // The character offset in the line
+}
+
+// LSPRange is a range in a text document, as defined by the Language
+// Server Protocol.  The end of the range is exclusive.
+type LSPRange struct {
+	Start LSPPosition `json:"start"` // The beginning of the range
+	End   LSPPosition `json:"end"`   // The end of the range
+}
+
+// lspOffset maps the column at which a character of a line begins to
+// its UTF-16 offset within the line.
+type lspOffset struct {
+	col  int // The column, as computed by FileLocation
+	char int // The UTF-16 offset
+}
+
+// lspLine is a helper that returns the offsets of the characters in
+// the designated line.  The final element of the offsets is the end
+// of the line.  An error is returned if the line has not been
+// retained.
+func (s *Source) lspLine(n int) ([]lspOffset, error) {
+	s.mu.Lock()
+	count := len(s.lines)
+	s.mu.Unlock()
+	if n < 1 || n > count {
+		return nil, ErrLSPPosition
+	}
+	line, _ := s.Line(n)
+
+	// Compute the offsets
+	offs := []lspOffset{{col: 1}}
+	var loc OffsetLocation = FileLocation{
+		B: FilePos{L: 1, C: 1},
+		E: FilePos{L: 1, C: 1},
+	}
+	char := 0
+	for _, ch := range line {
+		ext := Extent{Bytes: utf8.RuneLen(ch), Runes: 1}
+		ext.Cols = s.Unit.Columns(ch, ext)
+		fl := loc.IncrExtent(ch, s.TabStop, ext).(FileLocation)
+		loc = fl
+
+		char += ColumnUTF16.Columns(ch, ext)
+		offs = append(offs, lspOffset{col: fl.E.C, char: char})
+	}
+
+	return offs, nil
+}
+
+// PositionToLSP converts a position within the source to an LSP
+// position.  A column within a character, such as a tab, is
+// converted to the beginning of that character; a column beyond the
+// end of the line is converted to the end of the line.
+func (s *Source) PositionToLSP(p FilePos) (LSPPosition, error) {
+	offs, err := s.lspLine(p.L)
+	if err != nil {
+		return LSPPosition{}, err
+	}
+
+	// Find the character
+	pos := LSPPosition{Line: p.L - 1}
+	for _, o := range offs {
+		if o.col > p.C {
+			break
+		}
+		pos.Character = o.char
+		if o.col == p.C {
+			break
+		}
+	}
+
+	return pos, nil
+}
+
+// PositionFromLSP converts an LSP position to a position within the
+// source.  A character offset within a surrogate pair is converted to
+// the beginning of the pair; an offset beyond the end of the line is
+// converted to the end of the line.  Only the line and column of the
+// position are set.
+func (s *Source) PositionFromLSP(p LSPPosition) (FilePos, error) {
+	offs, err := s.lspLine(p.Line + 1)
+	if err != nil {
+		return FilePos{}, err
+	}
+
+	// Find the column
+	pos := FilePos{L: p.Line + 1}
+	for _, o := range offs {
+		if o.char > p.Character {
+			break
+		}
+		pos.C = o.col
+	}
+
+	return pos, nil
+}
+
+// ToLSP converts a location within the source to an LSP range.  The
+// location must be a FileLocation for the source's file.
+func (s *Source) ToLSP(loc Location) (LSPRange, error) {
+	fl, ok := loc.(FileLocation)
+	if !ok || fl.File != s.File {
+		return LSPRange{}, ErrLSPPosition
+	}
+
+	start, err := s.PositionToLSP(fl.B)
+	if err != nil {
+		return LSPRange{}, err
+	}
+	end, err := s.PositionToLSP(fl.E)
+	if err != nil {
+		return LSPRange{}, err
+	}
+
+	return LSPRange{
+		Start: start,
+		End:   end,
+	}, nil
+}
+
+// FromLSP converts an LSP range to a location within the source.
+func (s *Source) FromLSP(r LSPRange) (FileLocation, error) {
+	b, err := s.PositionFromLSP(r.Start)
+	if err != nil {
+		return FileLocation{}, err
+	}
+	e, err := s.PositionFromLSP(r.End)
+	if err != nil {
+		return FileLocation{}, err
+	}
+
+	return FileLocation{
+		File: s.File,
+		B:    b,
+		E:    e,
+	}, nil
+}
+
+// ToLSP converts a location to an LSP range, using the retained text
+// of the location's file.  The location must be a FileLocation,
+// possibly wrapped in an IncludeLocation.
+func (r *SourceRegistry) ToLSP(loc Location) (LSPRange, error) {
+	if il, ok := loc.(IncludeLocation); ok {
+		loc = il.Loc
+	}
+	fl, ok := loc.(FileLocation)
+	if !ok {
+		return LSPRange{}, ErrLSPPosition
+	}
+	src := r.Source(fl.File)
+	if src == nil {
+		return LSPRange{}, ErrLSPPosition
+	}
+
+	return src.ToLSP(fl)
+}
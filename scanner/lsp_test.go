@@ -0,0 +1,309 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLSPRangeJSON(t *testing.T) {
+	obj := LSPRange{
+		Start: LSPPosition{Line: 1, Character: 2},
+		End:   LSPPosition{Line: 3, Character: 4},
+	}
+
+	result, err := json.Marshal(obj)
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"start":{"line":1,"character":2},"end":{"line":3,"character":4}}`, string(result))
+}
+
+func TestSourceLSPLineBase(t *testing.T) {
+	src := NewSourceRegistry().Add("file", "a\t\U0001f600b\n", 4)
+
+	result, err := src.lspLine(1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []lspOffset{
+		{col: 1, char: 0},
+		{col: 2, char: 1},
+		{col: 5, char: 2},
+		{col: 6, char: 4},
+		{col: 7, char: 5},
+	}, result)
+}
+
+func TestSourceLSPLineFinal(t *testing.T) {
+	src := NewSourceRegistry().Add("file", "a\n", 4)
+
+	result, err := src.lspLine(2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []lspOffset{{col: 1}}, result)
+}
+
+func TestSourceLSPLineMissing(t *testing.T) {
+	src := NewSourceRegistry().Add("file", "a\n", 4)
+
+	result, err := src.lspLine(3)
+
+	assert.Same(t, ErrLSPPosition, err)
+	assert.Nil(t, result)
+}
+
+func TestSourceLSPLineZero(t *testing.T) {
+	src := NewSourceRegistry().Add("file", "a\n", 4)
+
+	result, err := src.lspLine(0)
+
+	assert.Same(t, ErrLSPPosition, err)
+	assert.Nil(t, result)
+}
+
+func TestSourcePositionToLSP(t *testing.T) {
+	src := NewSourceRegistry().Add("file", "x\na\t\U0001f600b\n", 4)
+
+	for c, expected := range map[int]int{
+		1: 0,
+		2: 1,
+		3: 1,
+		5: 2,
+		6: 4,
+		7: 5,
+		9: 5,
+	} {
+		result, err := src.PositionToLSP(FilePos{L: 2, C: c})
+
+		assert.NoError(t, err)
+		assert.Equal(t, LSPPosition{Line: 1, Character: expected}, result, "column %d", c)
+	}
+}
+
+func TestSourcePositionToLSPMissing(t *testing.T) {
+	src := NewSourceRegistry().Add("file", "x\n", 4)
+
+	result, err := src.PositionToLSP(FilePos{L: 5, C: 1})
+
+	assert.Same(t, ErrLSPPosition, err)
+	assert.Equal(t, LSPPosition{}, result)
+}
+
+func TestSourcePositionFromLSP(t *testing.T) {
+	src := NewSourceRegistry().Add("file", "x\na\t\U0001f600b\n", 4)
+
+	for char, expected := range map[int]int{
+		0: 1,
+		1: 2,
+		2: 5,
+		3: 5,
+		4: 6,
+		5: 7,
+		9: 7,
+	} {
+		result, err := src.PositionFromLSP(LSPPosition{Line: 1, Character: char})
+
+		assert.NoError(t, err)
+		assert.Equal(t, FilePos{L: 2, C: expected}, result, "character %d", char)
+	}
+}
+
+func TestSourcePositionFromLSPMissing(t *testing.T) {
+	src := NewSourceRegistry().Add("file", "x\n", 4)
+
+	result, err := src.PositionFromLSP(LSPPosition{Line: 4})
+
+	assert.Same(t, ErrLSPPosition, err)
+	assert.Equal(t, FilePos{}, result)
+}
+
+func TestSourceToLSPBase(t *testing.T) {
+	src := NewSourceRegistry().Add("file", "x\na\t\U0001f600b\n", 4)
+
+	result, err := src.ToLSP(FileLocation{
+		File: "file",
+		B:    FilePos{L: 2, C: 5},
+		E:    FilePos{L: 3, C: 1},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, LSPRange{
+		Start: LSPPosition{Line: 1, Character: 2},
+		End:   LSPPosition{Line: 2, Character: 0},
+	}, result)
+}
+
+func TestSourceToLSPOtherFile(t *testing.T) {
+	src := NewSourceRegistry().Add("file", "x\n", 4)
+
+	result, err := src.ToLSP(FileLocation{
+		File: "other",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 2},
+	})
+
+	assert.Same(t, ErrLSPPosition, err)
+	assert.Equal(t, LSPRange{}, result)
+}
+
+func TestSourceToLSPOtherLocation(t *testing.T) {
+	src := NewSourceRegistry().Add("file", "x\n", 4)
+
+	result, err := src.ToLSP(ArgLocation{})
+
+	assert.Same(t, ErrLSPPosition, err)
+	assert.Equal(t, LSPRange{}, result)
+}
+
+func TestSourceToLSPBadStart(t *testing.T) {
+	src := NewSourceRegistry().Add("file", "x\n", 4)
+
+	result, err := src.ToLSP(FileLocation{
+		File: "file",
+		B:    FilePos{L: 5, C: 1},
+		E:    FilePos{L: 1, C: 2},
+	})
+
+	assert.Same(t, ErrLSPPosition, err)
+	assert.Equal(t, LSPRange{}, result)
+}
+
+func TestSourceToLSPBadEnd(t *testing.T) {
+	src := NewSourceRegistry().Add("file", "x\n", 4)
+
+	result, err := src.ToLSP(FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 5, C: 2},
+	})
+
+	assert.Same(t, ErrLSPPosition, err)
+	assert.Equal(t, LSPRange{}, result)
+}
+
+func TestSourceFromLSPBase(t *testing.T) {
+	src := NewSourceRegistry().Add("file", "x\na\t\U0001f600b\n", 4)
+
+	result, err := src.FromLSP(LSPRange{
+		Start: LSPPosition{Line: 1, Character: 2},
+		End:   LSPPosition{Line: 1, Character: 5},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, FileLocation{
+		File: "file",
+		B:    FilePos{L: 2, C: 5},
+		E:    FilePos{L: 2, C: 7},
+	}, result)
+}
+
+func TestSourceFromLSPBadStart(t *testing.T) {
+	src := NewSourceRegistry().Add("file", "x\n", 4)
+
+	result, err := src.FromLSP(LSPRange{
+		Start: LSPPosition{Line: 4},
+	})
+
+	assert.Same(t, ErrLSPPosition, err)
+	assert.Equal(t, FileLocation{}, result)
+}
+
+func TestSourceFromLSPBadEnd(t *testing.T) {
+	src := NewSourceRegistry().Add("file", "x\n", 4)
+
+	result, err := src.FromLSP(LSPRange{
+		End: LSPPosition{Line: 4},
+	})
+
+	assert.Same(t, ErrLSPPosition, err)
+	assert.Equal(t, FileLocation{}, result)
+}
+
+func TestSourceRegistryToLSPBase(t *testing.T) {
+	reg := NewSourceRegistry()
+	reg.Add("file", "x\n\U0001f600b\n", 8)
+
+	result, err := reg.ToLSP(IncludeLocation{
+		Loc: FileLocation{
+			File: "file",
+			B:    FilePos{L: 2, C: 2},
+			E:    FilePos{L: 2, C: 3},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, LSPRange{
+		Start: LSPPosition{Line: 1, Character: 2},
+		End:   LSPPosition{Line: 1, Character: 3},
+	}, result)
+}
+
+func TestSourceRegistryToLSPOtherLocation(t *testing.T) {
+	reg := NewSourceRegistry()
+
+	result, err := reg.ToLSP(ArgLocation{})
+
+	assert.Same(t, ErrLSPPosition, err)
+	assert.Equal(t, LSPRange{}, result)
+}
+
+func TestSourceRegistryToLSPNoSource(t *testing.T) {
+	reg := NewSourceRegistry()
+
+	result, err := reg.ToLSP(FileLocation{File: "file"})
+
+	assert.Same(t, ErrLSPPosition, err)
+	assert.Equal(t, LSPRange{}, result)
+}
+
+func TestLSPRoundTrip(t *testing.T) {
+	reg := NewSourceRegistry()
+	s := NewFileScanner(strings.NewReader("if\t\"\U0001f600\" {\r\n\tx\r\n}\r\n"), FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}, Retain(reg))
+	_, err := scanAll(s)
+	require.NoError(t, err)
+
+	// Rescan the retained text and convert each character
+	src := reg.Source("file")
+	s = NewFileScanner(strings.NewReader(src.Text()), FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	})
+	for {
+		ch, err := s.Next()
+		require.NoError(t, err)
+		if ch.Rune == EOF {
+			break
+		}
+		fl := ch.Loc.(FileLocation)
+
+		r, err := reg.ToLSP(fl)
+		require.NoError(t, err)
+		result, err := src.FromLSP(r)
+		require.NoError(t, err)
+		assert.Equal(t, fl.B.L, result.B.L)
+		assert.Equal(t, fl.B.C, result.B.C)
+		assert.Equal(t, fl.E.L, result.E.L)
+		assert.Equal(t, fl.E.C, result.E.C)
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+// TextScanner is an optional interface for Scanner implementations
+// that can return the text of their source input between two
+// locations, without copying it.
+type TextScanner interface {
+	Scanner
+
+	// TextSpan returns the text of the source input from the
+	// beginning of the first location through the end of the
+	// last location, both of which must have been returned by
+	// the scanner.  If the text is not available, the boolean
+	// return value will be false.
+	TextSpan(first, last Location) (string, bool)
+}
+
+// MemoryScanner is an implementation of Scanner that scans a string
+// or byte slice held in memory.  Unlike a FileScanner reading from a
+// bytes.Buffer, a MemoryScanner decodes the characters in place and
+// does not copy the input.  The byte offset of each character in the
+// input is available from the Offset method, and the text covered by
+// a location may be retrieved as a substring of the input with the
+// Text method.
+type MemoryScanner struct {
+	str   string               // The input, if it is a string
+	buf   []byte               // The input, if it is a byte slice
+	bytes bool                 // Whether the input is a byte slice
+	end   int                  // The length of the input
+	pos   int                  // The offset of the next character
+	off   int                  // The offset of the last character
+	base  int                  // The byte offset of the initial location
+	loc   Location             // The location of the last character
+	ts    int                  // The tabstop in use
+	ls    LineStyle            // Current line ending style
+	cu    ColumnUnit           // Unit for counting columns
+	enc   EncodingErrorHandler // Handler for encoding errors
+}
+
+// MemoryOption is an option that may be passed to the
+// NewStringScanner and NewBytesScanner functions.
+type MemoryOption interface {
+	// memoryApply applies the option to MemoryScanner.
+	memoryApply(s *MemoryScanner)
+}
+
+// newMemoryScanner is a helper for NewStringScanner and
+// NewBytesScanner that constructs a MemoryScanner and applies the
+// options.
+func newMemoryScanner(loc Location, options []MemoryOption) *MemoryScanner {
+	s := &MemoryScanner{
+		loc: loc,
+		ts:  DefaultTabStop,
+		ls:  UnknownLineStyle,
+	}
+	if fl, ok := loc.(FileLocation); ok {
+		s.base = fl.E.O
+	}
+
+	// Apply the options
+	for _, opt := range options {
+		opt.memoryApply(s)
+	}
+
+	return s
+}
+
+// NewStringScanner constructs a MemoryScanner that scans a string,
+// which must be encoded in UTF-8.  The location is the location at
+// which the string begins, as for NewFileScanner.  The LineEndings,
+// TabStop, ColumnUnit, and EncodingError options may be used.
+func NewStringScanner(text string, loc Location, options ...MemoryOption) *MemoryScanner {
+	s := newMemoryScanner(loc, options)
+	s.str = text
+	s.end = len(text)
+
+	return s
+}
+
+// NewBytesScanner is similar to NewStringScanner, except that it
+// scans a byte slice.  The byte slice must not be modified while the
+// scanner is in use.
+func NewBytesScanner(data []byte, loc Location, options ...MemoryOption) *MemoryScanner {
+	s := newMemoryScanner(loc, options)
+	s.buf = data
+	s.bytes = true
+	s.end = len(data)
+
+	return s
+}
+
+// decode is a helper that decodes the rune at the designated offset
+// in the input.  At the end of the input, it returns EOF.
+func (s *MemoryScanner) decode(pos int) (rune, int) {
+	switch {
+	case pos >= s.end:
+		return EOF, 0
+	case s.bytes:
+		return utf8.DecodeRune(s.buf[pos:])
+	}
+
+	return utf8.DecodeRuneInString(s.str[pos:])
+}
+
+// read is a helper for Next that reads the next rune, handling
+// encoding errors.  Invalid bytes skipped at the direction of the
+// encoding error handler are included in the extent of the rune.  It
+// returns the rune and its width, along with the error to report, if
+// any; as with FileScanner, an unhandled encoding error is reported
+// with an EOF.
+func (s *MemoryScanner) read(begin int) (rune, int, error) {
+	for {
+		ch, width := s.decode(s.pos)
+		if ch != utf8.RuneError || width != 1 {
+			return ch, width, nil
+		}
+
+		// Report the encoding error
+		err := LocationError(s.incr(utf8.RuneError, begin, s.pos+width), ErrBadEncoding)
+		if s.enc != nil {
+			err = s.enc.Handle(err)
+		}
+		switch {
+		case err == nil: // Replace the invalid input
+			return ch, width, nil
+
+		case !errors.Is(err, ErrSkipInvalid): // Handler didn't handle it
+			return EOF, 0, err
+		}
+		s.pos += width
+	}
+}
+
+// incr is a helper that computes the location of a character which
+// covers the designated range of offsets of the input.
+func (s *MemoryScanner) incr(ch rune, begin, end int) Location {
+	ol, ok := s.loc.(OffsetLocation)
+	if !ok {
+		return s.loc.Incr(ch, s.ts)
+	}
+
+	ext := Extent{Bytes: end - begin}
+	for p := begin; p < end; ext.Runes++ {
+		_, w := s.decode(p)
+		p += w
+	}
+	ext.Cols = s.cu.Columns(ch, ext)
+
+	return ol.IncrExtent(ch, s.ts, ext)
+}
+
+// Next returns the next character from the stream as a Char, which
+// will include the character's location.  If an error was
+// encountered, that will also be returned.
+func (s *MemoryScanner) Next() (Char, error) {
+	begin := s.pos
+	ch, width, err := s.read(begin)
+	s.off = s.pos
+	pos := s.pos + width
+
+	// If it's a line terminator, do line ending handling
+	if isTerminator(s.ls, ch) {
+		var unread bool
+		ch, _, unread, s.ls = lineEnding(s.ls, ch, func() rune {
+			var next rune
+			next, width = s.decode(pos)
+			pos += width
+			return next
+		})
+		if unread {
+			pos -= width
+		}
+	}
+
+	// Compute the location
+	s.loc = s.incr(ch, begin, pos)
+	s.pos = pos
+
+	// Stop scanning if an error was encountered
+	if err != nil {
+		s.pos = s.end
+	}
+
+	return Char{
+		Rune: ch,
+		Loc:  s.loc,
+	}, err
+}
+
+// Offset returns the byte offset within the input of the character
+// most recently returned by Next.
+func (s *MemoryScanner) Offset() int {
+	return s.off
+}
+
+// span is a helper for TextSpan and Bytes that computes the range of
+// offsets of the input from the beginning of the first location
+// through the end of the last location.
+func (s *MemoryScanner) span(first, last Location) (int, int, bool) {
+	fl, ok := first.(FileLocation)
+	if !ok {
+		return 0, 0, false
+	}
+	ll, ok := last.(FileLocation)
+	if !ok {
+		return 0, 0, false
+	}
+
+	begin, end := fl.B.O-s.base, ll.E.O-s.base
+	if begin < 0 || end < begin || end > s.end {
+		return 0, 0, false
+	}
+
+	return begin, end, true
+}
+
+// Text returns the text of the input covered by a location returned
+// by the scanner, or by a location spanning several such locations.
+// The text is the original text of the input, without line ending
+// conversion.  If the input is a string, the text is a substring of
+// it, and no copy is made.  If the text is not available, such as
+// when the scanner was not constructed with a FileLocation, the
+// boolean return value will be false.
+func (s *MemoryScanner) Text(loc Location) (string, bool) {
+	return s.TextSpan(loc, loc)
+}
+
+// TextSpan is similar to Text, except that it returns the text from
+// the beginning of the first location through the end of the last
+// location.  Passing the locations of the first and last characters
+// of a token avoids computing a location spanning the token.
+func (s *MemoryScanner) TextSpan(first, last Location) (string, bool) {
+	begin, end, ok := s.span(first, last)
+	if !ok {
+		return "", false
+	} else if s.bytes {
+		return string(s.buf[begin:end]), true
+	}
+
+	return s.str[begin:end], true
+}
+
+// Bytes is similar to Text, except that it returns a byte slice.  If
+// the input is a byte slice, the result is a slice of it, and no copy
+// is made.
+func (s *MemoryScanner) Bytes(loc Location) ([]byte, bool) {
+	begin, end, ok := s.span(loc, loc)
+	if !ok {
+		return nil, false
+	} else if s.bytes {
+		return s.buf[begin:end], true
+	}
+
+	return []byte(s.str[begin:end]), true
+}
@@ -0,0 +1,336 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryScannerImplementsTextScanner(t *testing.T) {
+	assert.Implements(t, (*TextScanner)(nil), &MemoryScanner{})
+}
+
+func TestNewStringScannerBase(t *testing.T) {
+	loc := fileLoc("file", 1, 1, 1)
+
+	result := NewStringScanner("text", loc)
+
+	assert.Equal(t, &MemoryScanner{
+		str: "text",
+		end: 4,
+		loc: loc,
+		ts:  DefaultTabStop,
+		ls:  UnknownLineStyle,
+	}, result)
+}
+
+func TestNewStringScannerOptions(t *testing.T) {
+	loc := FileLocation{
+		File: "file",
+		B:    FilePos{L: 2, C: 1, O: 10, R: 8},
+		E:    FilePos{L: 2, C: 1, O: 10, R: 8},
+	}
+
+	result := NewStringScanner("text", loc, TabStop(4), LineEndings(UNIXLineStyle), EncodingError(SkipInvalid))
+
+	assert.Equal(t, &MemoryScanner{
+		str:  "text",
+		end:  4,
+		base: 10,
+		loc:  loc,
+		ts:   4,
+		ls:   UNIXLineStyle,
+		enc:  SkipInvalid,
+	}, result)
+}
+
+func TestNewBytesScanner(t *testing.T) {
+	data := []byte("text")
+	loc := fileLoc("file", 1, 1, 1)
+
+	result := NewBytesScanner(data, loc)
+
+	assert.Equal(t, &MemoryScanner{
+		buf:   data,
+		bytes: true,
+		end:   4,
+		loc:   loc,
+		ts:    DefaultTabStop,
+		ls:    UnknownLineStyle,
+	}, result)
+}
+
+func TestMemoryScannerNext(t *testing.T) {
+	obj := NewStringScanner("a\té\r\nb\r", FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	})
+	expected := []struct {
+		r   rune
+		loc FileLocation
+		off int
+	}{
+		{'a', FileLocation{File: "file", B: FilePos{L: 1, C: 1}, E: FilePos{L: 1, C: 2, O: 1, R: 1}}, 0},
+		{'\t', FileLocation{File: "file", B: FilePos{L: 1, C: 2, O: 1, R: 1}, E: FilePos{L: 1, C: 9, O: 2, R: 2}}, 1},
+		{'é', FileLocation{File: "file", B: FilePos{L: 1, C: 9, O: 2, R: 2}, E: FilePos{L: 1, C: 10, O: 4, R: 3}}, 2},
+		{'\n', FileLocation{File: "file", B: FilePos{L: 1, C: 10, O: 4, R: 3}, E: FilePos{L: 2, C: 1, O: 6, R: 5}}, 4},
+		{'b', FileLocation{File: "file", B: FilePos{L: 2, C: 1, O: 6, R: 5}, E: FilePos{L: 2, C: 2, O: 7, R: 6}}, 6},
+		{' ', FileLocation{File: "file", B: FilePos{L: 2, C: 2, O: 7, R: 6}, E: FilePos{L: 2, C: 3, O: 8, R: 7}}, 7},
+		{EOF, FileLocation{File: "file", B: FilePos{L: 2, C: 3, O: 8, R: 7}, E: FilePos{L: 2, C: 3, O: 8, R: 7}}, 8},
+		{EOF, FileLocation{File: "file", B: FilePos{L: 2, C: 3, O: 8, R: 7}, E: FilePos{L: 2, C: 3, O: 8, R: 7}}, 8},
+	}
+
+	for _, exp := range expected {
+		ch, err := obj.Next()
+
+		require.NoError(t, err)
+		assert.Equal(t, Char{Rune: exp.r, Loc: exp.loc}, ch)
+		assert.Equal(t, exp.off, obj.Offset())
+	}
+}
+
+func TestMemoryScannerNextMacLineEndings(t *testing.T) {
+	obj := NewStringScanner("a\rb", FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	})
+
+	result, err := scanAll(obj)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "a\nb", result)
+}
+
+func TestMemoryScannerNextBytes(t *testing.T) {
+	obj := NewBytesScanner([]byte("a\r\nb"), FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	})
+
+	result, err := scanAll(obj)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "a\nb", result)
+}
+
+func TestMemoryScannerNextOtherLocation(t *testing.T) {
+	loc1 := &mockLocation{}
+	loc2 := &mockLocation{}
+	loc1.On("Incr", 'a', DefaultTabStop).Return(loc2)
+	obj := NewStringScanner("a", loc1)
+
+	result, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: 'a', Loc: loc2}, result)
+	loc1.AssertExpectations(t)
+}
+
+func TestMemoryScannerNextBadEncoding(t *testing.T) {
+	obj := NewStringScanner("a\xffb", FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	})
+
+	result, err := scanAll(obj)
+
+	assert.Equal(t, "a", result)
+	assert.True(t, errors.Is(err, ErrBadEncoding))
+	assert.Equal(t, FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 2, O: 1, R: 1},
+		E:    FilePos{L: 1, C: 3, O: 2, R: 2},
+	}, LocationOf(err))
+	ch, err := obj.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, EOF, ch.Rune)
+}
+
+func TestMemoryScannerNextReplaceInvalid(t *testing.T) {
+	obj := NewStringScanner("a\xffb", FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}, EncodingError(ReplaceInvalid))
+
+	result, err := scanAll(obj)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "a�b", result)
+}
+
+func TestMemoryScannerNextSkipInvalid(t *testing.T) {
+	obj := NewStringScanner("a\xff\xffb", FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}, EncodingError(SkipInvalid))
+
+	_, err := obj.Next()
+	require.NoError(t, err)
+	result, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: 'b', Loc: FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 2, O: 1, R: 1},
+		E:    FilePos{L: 1, C: 3, O: 4, R: 4},
+	}}, result)
+	assert.Equal(t, 3, obj.Offset())
+}
+
+func TestMemoryScannerText(t *testing.T) {
+	obj := NewStringScanner("foo bar", FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1, O: 10},
+		E:    FilePos{L: 1, C: 1, O: 10},
+	})
+
+	result, ok := obj.Text(FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 5, O: 14},
+		E:    FilePos{L: 1, C: 8, O: 17},
+	})
+
+	assert.True(t, ok)
+	assert.Equal(t, "bar", result)
+}
+
+func TestMemoryScannerTextBytes(t *testing.T) {
+	obj := NewBytesScanner([]byte("foo bar"), fileLoc("file", 1, 1, 1))
+
+	result, ok := obj.Text(FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 5, O: 4},
+		E:    FilePos{L: 1, C: 8, O: 7},
+	})
+
+	assert.True(t, ok)
+	assert.Equal(t, "bar", result)
+}
+
+func TestMemoryScannerTextOtherLocation(t *testing.T) {
+	obj := NewStringScanner("foo bar", fileLoc("file", 1, 1, 1))
+
+	result, ok := obj.Text(&mockLocation{})
+
+	assert.False(t, ok)
+	assert.Equal(t, "", result)
+}
+
+func TestMemoryScannerTextOutOfRange(t *testing.T) {
+	obj := NewStringScanner("foo bar", fileLoc("file", 1, 1, 1))
+
+	result, ok := obj.Text(FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 5, O: 4},
+		E:    FilePos{L: 1, C: 9, O: 8},
+	})
+
+	assert.False(t, ok)
+	assert.Equal(t, "", result)
+}
+
+func TestMemoryScannerTextSpan(t *testing.T) {
+	obj := NewStringScanner("foo bar", fileLoc("file", 1, 1, 1))
+
+	result, ok := obj.TextSpan(FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 3, O: 2},
+		E:    FilePos{L: 1, C: 4, O: 3},
+	}, FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 5, O: 4},
+		E:    FilePos{L: 1, C: 6, O: 5},
+	})
+
+	assert.True(t, ok)
+	assert.Equal(t, "o b", result)
+}
+
+func TestMemoryScannerTextSpanReversed(t *testing.T) {
+	obj := NewStringScanner("foo bar", fileLoc("file", 1, 1, 1))
+
+	result, ok := obj.TextSpan(FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 5, O: 4},
+		E:    FilePos{L: 1, C: 6, O: 5},
+	}, FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 3, O: 2},
+		E:    FilePos{L: 1, C: 4, O: 3},
+	})
+
+	assert.False(t, ok)
+	assert.Equal(t, "", result)
+}
+
+func TestMemoryScannerTextSpanOtherLocation(t *testing.T) {
+	obj := NewStringScanner("foo bar", fileLoc("file", 1, 1, 1))
+
+	result, ok := obj.TextSpan(FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1, O: 0},
+		E:    FilePos{L: 1, C: 2, O: 1},
+	}, &mockLocation{})
+
+	assert.False(t, ok)
+	assert.Equal(t, "", result)
+}
+
+func TestMemoryScannerBytes(t *testing.T) {
+	data := []byte("foo bar")
+	obj := NewBytesScanner(data, fileLoc("file", 1, 1, 1))
+
+	result, ok := obj.Bytes(FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 5, O: 4},
+		E:    FilePos{L: 1, C: 8, O: 7},
+	})
+
+	assert.True(t, ok)
+	assert.Equal(t, []byte("bar"), result)
+	assert.Same(t, &data[4], &result[0])
+}
+
+func TestMemoryScannerBytesString(t *testing.T) {
+	obj := NewStringScanner("foo bar", fileLoc("file", 1, 1, 1))
+
+	result, ok := obj.Bytes(FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 5, O: 4},
+		E:    FilePos{L: 1, C: 8, O: 7},
+	})
+
+	assert.True(t, ok)
+	assert.Equal(t, []byte("bar"), result)
+}
+
+func TestMemoryScannerBytesOtherLocation(t *testing.T) {
+	obj := NewBytesScanner([]byte("foo bar"), fileLoc("file", 1, 1, 1))
+
+	result, ok := obj.Bytes(&mockLocation{})
+
+	assert.False(t, ok)
+	assert.Nil(t, result)
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// pathError is a helper for Open and OpenFS that ensures that an
+// error opening a file includes the path of the file.
+func pathError(name string, err error) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		return err
+	}
+
+	return &fs.PathError{
+		Op:   "open",
+		Path: name,
+		Err:  err,
+	}
+}
+
+// openFile is a helper for Open and OpenFS that constructs a
+// FileScanner for an open file.  The initial location is at line 1,
+// column 1 of the named file, and the file is closed when the scanner
+// reaches the end of it.
+func openFile(f io.ReadCloser, name string, options []FileOption) *FileScanner {
+	s := NewFileScanner(f, FileLocation{
+		File: name,
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}, options...)
+	s.cls = f
+
+	return s
+}
+
+// Open opens the file at the designated path and constructs a
+// FileScanner to read it.  The locations of the characters are
+// FileLocation values with the path as the file name.  The file is
+// closed when the scanner reaches the end of it, or when the
+// scanner's Close method is called.
+func Open(path string, options ...FileOption) (*FileScanner, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, pathError(path, err)
+	}
+
+	return openFile(f, path, options), nil
+}
+
+// OpenFS is similar to Open, except that the file is opened from the
+// designated file system.
+func OpenFS(fsys fs.FS, name string, options ...FileOption) (*FileScanner, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, pathError(name, err)
+	}
+
+	return openFile(f, name, options), nil
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockFS struct {
+	mock.Mock
+}
+
+func (m *mockFS) Open(name string) (fs.File, error) {
+	args := m.MethodCalled("Open", name)
+
+	if tmp := args.Get(0); tmp != nil {
+		return tmp.(fs.File), args.Error(1)
+	}
+
+	return nil, args.Error(1)
+}
+
+func TestPathErrorBase(t *testing.T) {
+	result := pathError("file", assert.AnError)
+
+	assert.Equal(t, &fs.PathError{
+		Op:   "open",
+		Path: "file",
+		Err:  assert.AnError,
+	}, result)
+}
+
+func TestPathErrorPathError(t *testing.T) {
+	err := &fs.PathError{
+		Op:   "open",
+		Path: "other",
+		Err:  assert.AnError,
+	}
+
+	result := pathError("file", err)
+
+	assert.Same(t, err, result)
+}
+
+func TestOpenFSBase(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dir/file.txt": &fstest.MapFile{Data: []byte("a\nbc")},
+	}
+
+	obj, err := OpenFS(fsys, "dir/file.txt")
+
+	require.NoError(t, err)
+	assert.Equal(t, FileLocation{
+		File: "dir/file.txt",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}, obj.loc)
+	assert.NotNil(t, obj.cls)
+	text, err := scanAll(obj)
+	require.NoError(t, err)
+	ch, _ := obj.Next()
+	loc := ch.Loc
+	assert.Equal(t, "a\nbc", text)
+	assert.Equal(t, FileLocation{
+		File: "dir/file.txt",
+		B:    FilePos{L: 2, C: 3, O: 4, R: 4},
+		E:    FilePos{L: 2, C: 3, O: 4, R: 4},
+	}, loc)
+	assert.Nil(t, obj.cls)
+}
+
+func TestOpenFSOptions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"file.txt": &fstest.MapFile{Data: []byte("a")},
+	}
+
+	obj, err := OpenFS(fsys, "file.txt", TabStop(4))
+
+	require.NoError(t, err)
+	assert.Equal(t, 4, obj.ts)
+}
+
+func TestOpenFSMissing(t *testing.T) {
+	fsys := fstest.MapFS{}
+
+	obj, err := OpenFS(fsys, "file.txt")
+
+	assert.Nil(t, obj)
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+	assert.Contains(t, err.Error(), "file.txt")
+}
+
+func TestOpenFSOtherError(t *testing.T) {
+	fsys := &mockFS{}
+	fsys.On("Open", "file.txt").Return(nil, assert.AnError)
+
+	obj, err := OpenFS(fsys, "file.txt")
+
+	assert.Nil(t, obj)
+	assert.Equal(t, &fs.PathError{
+		Op:   "open",
+		Path: "file.txt",
+		Err:  assert.AnError,
+	}, err)
+	fsys.AssertExpectations(t)
+}
+
+func TestOpenBase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	require.NoError(t, os.WriteFile(path, []byte("a\tb"), 0o644))
+
+	obj, err := Open(path)
+
+	require.NoError(t, err)
+	f := obj.cls.(*os.File)
+	text, err := scanAll(obj)
+	require.NoError(t, err)
+	ch, _ := obj.Next()
+	loc := ch.Loc
+	assert.Equal(t, "a\tb", text)
+	assert.Equal(t, FileLocation{
+		File: path,
+		B:    FilePos{L: 1, C: 10, O: 3, R: 3},
+		E:    FilePos{L: 1, C: 10, O: 3, R: 3},
+	}, loc)
+	_, err = f.Read(make([]byte, 1))
+	assert.True(t, errors.Is(err, fs.ErrClosed))
+}
+
+func TestOpenClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	require.NoError(t, os.WriteFile(path, []byte("abc"), 0o644))
+	obj, err := Open(path)
+	require.NoError(t, err)
+	f := obj.cls.(*os.File)
+
+	err = obj.Close()
+
+	assert.NoError(t, err)
+	_, err = f.Read(make([]byte, 1))
+	assert.True(t, errors.Is(err, fs.ErrClosed))
+}
+
+func TestOpenMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.txt")
+
+	obj, err := Open(path)
+
+	assert.Nil(t, obj)
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+	assert.Contains(t, err.Error(), path)
+}
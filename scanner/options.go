@@ -14,6 +14,10 @@
 
 package scanner
 
+import (
+	"context"
+)
+
 // FileOption is an option that may be passed to the NewFileScanner
 // function.
 type FileOption interface {
@@ -34,26 +38,47 @@ type ArgOption interface {
 	argApply(o *argOptions)
 }
 
-// lineEndings is the type that stores the line ending style to use.
-type lineEndings struct {
+// asyncOptions is a set of options for NewAsyncScanner.
+type asyncOptions struct {
+	batch int             // The number of characters per batch
+	buf   int             // The number of batches to buffer
+	ctx   context.Context // The context to monitor
+}
+
+// AsyncOption is an option that may be passed to the NewAsyncScanner
+// function.
+type AsyncOption interface {
+	// asyncApply applies the option to asyncOptions.
+	asyncApply(o *asyncOptions)
+}
+
+// LineEndingsOption is the type that stores the line ending style to
+// use.
+type LineEndingsOption struct {
 	ls LineStyle // The line ending style to use
 }
 
 // fileApply applies the option to FileScanner.
-func (o lineEndings) fileApply(s *FileScanner) {
+func (o LineEndingsOption) fileApply(s *FileScanner) {
+	s.ls = o.ls
+}
+
+// memoryApply applies the option to MemoryScanner.
+func (o LineEndingsOption) memoryApply(s *MemoryScanner) {
 	s.ls = o.ls
 }
 
-// LineEndings is a file scanner option that may be used to set the
-// preferred line ending style.  A line ending style is an instance of
-// LineStyle that controls how the scanner recognizes newlines.  The
-// scanner always converts line endings into single newlines.
-func LineEndings(ls LineStyle) FileOption {
-	return lineEndings{ls: ls}
+// LineEndings is an option that may be passed to NewFileScanner,
+// NewStringScanner, or NewBytesScanner to set the preferred line
+// ending style.  A line ending style is an instance of LineStyle that
+// controls how the scanner recognizes newlines.  The scanner always
+// converts line endings into single newlines.
+func LineEndings(ls LineStyle) LineEndingsOption {
+	return LineEndingsOption{ls: ls}
 }
 
-// TabStop is a file scanner option that specifies the tab stop to
-// apply.  The default tab stop is 8.
+// TabStop is a file or memory scanner option that specifies the tab
+// stop to apply.  The default tab stop is 8.
 type TabStop int
 
 // fileApply applies the option to FileScanner.
@@ -61,6 +86,77 @@ func (o TabStop) fileApply(s *FileScanner) {
 	s.ts = int(o)
 }
 
+// memoryApply applies the option to MemoryScanner.
+func (o TabStop) memoryApply(s *MemoryScanner) {
+	s.ts = int(o)
+}
+
+// LineContinuation is a file scanner option that specifies an escape
+// character, typically a backslash, which splices lines together when
+// followed by a newline.  The escape character and the newline are
+// removed from the characters returned by the scanner, but the
+// locations of the characters reflect their physical positions in
+// the source.  The default is no line continuations.
+type LineContinuation rune
+
+// fileApply applies the option to FileScanner.
+func (o LineContinuation) fileApply(s *FileScanner) {
+	s.lc = rune(o)
+}
+
+// MaxInputSize is a file scanner option that specifies the maximum
+// number of bytes the scanner will read from its source.  Once the
+// limit is exceeded, the scanner reports ErrInputTooLarge.  The
+// default is no limit.
+type MaxInputSize int64
+
+// fileApply applies the option to FileScanner.
+func (o MaxInputSize) fileApply(s *FileScanner) {
+	s.max = int64(o)
+}
+
+// BatchSize is an asynchronous scanner option that specifies the
+// maximum number of characters the goroutine started by
+// NewAsyncScanner collects before handing them to the consumer.  The
+// default is DefaultBatchSize.
+type BatchSize int
+
+// asyncApply applies the option to asyncOptions.
+func (o BatchSize) asyncApply(opts *asyncOptions) {
+	opts.batch = int(o)
+}
+
+// BufferSize is an asynchronous scanner option that specifies the
+// number of batches of characters that may be waiting for the
+// consumer before the goroutine started by NewAsyncScanner blocks.
+// The default is DefaultBufferSize.
+type BufferSize int
+
+// asyncApply applies the option to asyncOptions.
+func (o BufferSize) asyncApply(opts *asyncOptions) {
+	opts.buf = int(o)
+}
+
+// sanitize is the type that stores the sanitization policy to apply.
+type sanitize struct {
+	policy SanitizePolicy // The sanitization policy
+}
+
+// fileApply applies the option to FileScanner.
+func (o sanitize) fileApply(s *FileScanner) {
+	policy := o.policy
+	s.san = &policy
+}
+
+// Sanitize is a file scanner option that specifies a policy for
+// sanitizing the input.  The policy controls the handling of control
+// characters, such as NUL, and of files that mix line ending styles.
+// The default is to pass control characters through and to allow
+// mixed line endings.
+func Sanitize(policy SanitizePolicy) FileOption {
+	return sanitize{policy: policy}
+}
+
 // EncodingErrorOption is the type that stores the encoding error
 // handler that the file scanner should use.
 type EncodingErrorOption struct {
@@ -77,13 +173,99 @@ func (eeo EncodingErrorOption) argApply(o *argOptions) {
 	o.opts = append(o.opts, eeo)
 }
 
-// EncodingError is an option that may be passed to either
-// NewFileScanner or NewArgumentScanner.  It is used to specify an
-// EncodingErrorHandler to use to handle encoding errors.
+// memoryApply applies the option to MemoryScanner.
+func (eeo EncodingErrorOption) memoryApply(s *MemoryScanner) {
+	s.enc = eeo.enc
+}
+
+// EncodingError is an option that may be passed to NewFileScanner,
+// NewArgumentScanner, NewStringScanner, or NewBytesScanner.  It is
+// used to specify an EncodingErrorHandler to use to handle encoding
+// errors.
 func EncodingError(enc EncodingErrorHandler) EncodingErrorOption {
 	return EncodingErrorOption{enc: enc}
 }
 
+// ContextOption is the type that stores the context that the file
+// scanner should monitor for cancellation.
+type ContextOption struct {
+	ctx context.Context // The context to monitor
+}
+
+// fileApply applies the option to FileScanner.
+func (co ContextOption) fileApply(s *FileScanner) {
+	s.ctx = co.ctx
+}
+
+// argApply applies the option to argOptions.
+func (co ContextOption) argApply(o *argOptions) {
+	o.opts = append(o.opts, co)
+}
+
+// asyncApply applies the option to asyncOptions.
+func (co ContextOption) asyncApply(o *asyncOptions) {
+	o.ctx = co.ctx
+}
+
+// Context is an option that may be passed to NewFileScanner,
+// NewArgumentScanner, or NewAsyncScanner.  It is used to specify a
+// context; once the context is cancelled, the scanner stops,
+// returning the context's error wrapped in a location error.
+func Context(ctx context.Context) ContextOption {
+	return ContextOption{ctx: ctx}
+}
+
+// retain is the type that stores the source registry the file
+// scanner should retain its text in.
+type retain struct {
+	reg *SourceRegistry // The registry to retain the text in
+}
+
+// fileApply applies the option to FileScanner.
+func (o retain) fileApply(s *FileScanner) {
+	s.reg = o.reg
+}
+
+// Retain is a file scanner option that causes the text returned by
+// the scanner to be retained in the specified SourceRegistry.  The
+// text is keyed by the File field of the scanner's initial location,
+// which must be a FileLocation; for other location types, the option
+// has no effect.
+func Retain(reg *SourceRegistry) FileOption {
+	return retain{reg: reg}
+}
+
+// encoding is the type that stores the decoder that the file scanner
+// should use.
+type encoding struct {
+	dec Decoder // The decoder to use
+	bom bool    // Whether to detect a byte order mark
+}
+
+// fileApply applies the option to FileScanner.
+func (o encoding) fileApply(s *FileScanner) {
+	s.dec = o.dec
+	s.bom = o.bom
+}
+
+// Encoding is a file scanner option that selects the Decoder to use
+// to decode the source.  Decoders are provided for UTF-8 (the
+// default), UTF-16, and Latin-1, but any implementation of Decoder
+// may be used.
+func Encoding(dec Decoder) FileOption {
+	return encoding{dec: dec}
+}
+
+// DetectEncoding is a file scanner option that detects the encoding
+// of the source from its byte order mark, if it has one.  UTF-8 and
+// both byte orders of UTF-16 are recognized, and the byte order mark
+// is stripped from the source.  If the source does not begin with a
+// byte order mark, the fallback Decoder is used; this may be nil to
+// select the default UTF-8 decoding.
+func DetectEncoding(fallback Decoder) FileOption {
+	return encoding{dec: fallback, bom: true}
+}
+
 // ArgJoiner is an argument option that specifies the string that
 // should logically be expected between each argument.  By default,
 // this is a single space (" ").
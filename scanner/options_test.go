@@ -15,6 +15,7 @@
 package scanner
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -37,26 +38,66 @@ func (m *mockArgOption) argApply(o *argOptions) {
 	m.MethodCalled("argApply", o)
 }
 
+func TestBatchSizeImplementsAsyncOption(t *testing.T) {
+	assert.Implements(t, (*AsyncOption)(nil), BatchSize(0))
+}
+
+func TestBatchSizeAsyncApply(t *testing.T) {
+	o := &asyncOptions{}
+	obj := BatchSize(42)
+
+	obj.asyncApply(o)
+
+	assert.Equal(t, &asyncOptions{batch: 42}, o)
+}
+
+func TestBufferSizeImplementsAsyncOption(t *testing.T) {
+	assert.Implements(t, (*AsyncOption)(nil), BufferSize(0))
+}
+
+func TestBufferSizeAsyncApply(t *testing.T) {
+	o := &asyncOptions{}
+	obj := BufferSize(42)
+
+	obj.asyncApply(o)
+
+	assert.Equal(t, &asyncOptions{buf: 42}, o)
+}
+
 func TestLineEndingsImplementsFileOption(t *testing.T) {
-	assert.Implements(t, (*FileOption)(nil), lineEndings{})
+	assert.Implements(t, (*FileOption)(nil), LineEndingsOption{})
+}
+
+func TestLineEndingsImplementsMemoryOption(t *testing.T) {
+	assert.Implements(t, (*MemoryOption)(nil), LineEndingsOption{})
 }
 
 func TestLineEndingsFileApply(t *testing.T) {
 	ls := &mockLineStyle{}
 	s := &FileScanner{}
-	obj := lineEndings{ls: ls}
+	obj := LineEndingsOption{ls: ls}
 
 	obj.fileApply(s)
 
 	assert.Same(t, ls, s.ls)
 }
 
+func TestLineEndingsMemoryApply(t *testing.T) {
+	ls := &mockLineStyle{}
+	s := &MemoryScanner{}
+	obj := LineEndingsOption{ls: ls}
+
+	obj.memoryApply(s)
+
+	assert.Same(t, ls, s.ls)
+}
+
 func TestLineEndings(t *testing.T) {
 	ls := &mockLineStyle{}
 
 	result := LineEndings(ls)
 
-	assert.Equal(t, lineEndings{ls: ls}, result)
+	assert.Equal(t, LineEndingsOption{ls: ls}, result)
 }
 
 func TestTabStopImplementsFileOption(t *testing.T) {
@@ -72,6 +113,66 @@ func TestTabStopFileApply(t *testing.T) {
 	assert.Equal(t, 42, s.ts)
 }
 
+func TestTabStopImplementsMemoryOption(t *testing.T) {
+	assert.Implements(t, (*MemoryOption)(nil), TabStop(0))
+}
+
+func TestTabStopMemoryApply(t *testing.T) {
+	s := &MemoryScanner{}
+	obj := TabStop(42)
+
+	obj.memoryApply(s)
+
+	assert.Equal(t, 42, s.ts)
+}
+
+func TestLineContinuationImplementsFileOption(t *testing.T) {
+	assert.Implements(t, (*FileOption)(nil), LineContinuation('\\'))
+}
+
+func TestLineContinuationFileApply(t *testing.T) {
+	s := &FileScanner{}
+	obj := LineContinuation('\\')
+
+	obj.fileApply(s)
+
+	assert.Equal(t, '\\', s.lc)
+}
+
+func TestMaxInputSizeImplementsFileOption(t *testing.T) {
+	assert.Implements(t, (*FileOption)(nil), MaxInputSize(0))
+}
+
+func TestMaxInputSizeFileApply(t *testing.T) {
+	s := &FileScanner{}
+	obj := MaxInputSize(4096)
+
+	obj.fileApply(s)
+
+	assert.Equal(t, int64(4096), s.max)
+}
+
+func TestSanitizeImplementsFileOption(t *testing.T) {
+	assert.Implements(t, (*FileOption)(nil), sanitize{})
+}
+
+func TestSanitizeFileApply(t *testing.T) {
+	s := &FileScanner{}
+	obj := sanitize{policy: SanitizePolicy{Control: ActionStrip}}
+
+	obj.fileApply(s)
+
+	assert.Equal(t, &SanitizePolicy{Control: ActionStrip}, s.san)
+}
+
+func TestSanitize(t *testing.T) {
+	policy := SanitizePolicy{Control: ActionStrip}
+
+	result := Sanitize(policy)
+
+	assert.Equal(t, sanitize{policy: policy}, result)
+}
+
 func TestEncodingErrorOptionImplementsFileOption(t *testing.T) {
 	assert.Implements(t, (*FileOption)(nil), EncodingErrorOption{})
 }
@@ -100,6 +201,20 @@ func TestEncodingErrorOptionArgApply(t *testing.T) {
 	assert.Equal(t, []FileOption{obj}, o.opts)
 }
 
+func TestEncodingErrorOptionImplementsMemoryOption(t *testing.T) {
+	assert.Implements(t, (*MemoryOption)(nil), EncodingErrorOption{})
+}
+
+func TestEncodingErrorOptionMemoryApply(t *testing.T) {
+	enc := &mockEncodingErrorHandler{}
+	s := &MemoryScanner{}
+	obj := EncodingErrorOption{enc: enc}
+
+	obj.memoryApply(s)
+
+	assert.Same(t, enc, s.enc)
+}
+
 func TestEncodingError(t *testing.T) {
 	enc := &mockEncodingErrorHandler{}
 
@@ -108,6 +223,56 @@ func TestEncodingError(t *testing.T) {
 	assert.Equal(t, EncodingErrorOption{enc: enc}, result)
 }
 
+func TestContextOptionImplementsFileOption(t *testing.T) {
+	assert.Implements(t, (*FileOption)(nil), ContextOption{})
+}
+
+func TestContextOptionImplementsArgOption(t *testing.T) {
+	assert.Implements(t, (*ArgOption)(nil), ContextOption{})
+}
+
+func TestContextOptionFileApply(t *testing.T) {
+	ctx := context.Background()
+	s := &FileScanner{}
+	obj := ContextOption{ctx: ctx}
+
+	obj.fileApply(s)
+
+	assert.Equal(t, ctx, s.ctx)
+}
+
+func TestContextOptionArgApply(t *testing.T) {
+	ctx := context.Background()
+	o := &argOptions{}
+	obj := ContextOption{ctx: ctx}
+
+	obj.argApply(o)
+
+	assert.Equal(t, []FileOption{obj}, o.opts)
+}
+
+func TestContextOptionImplementsAsyncOption(t *testing.T) {
+	assert.Implements(t, (*AsyncOption)(nil), ContextOption{})
+}
+
+func TestContextOptionAsyncApply(t *testing.T) {
+	ctx := context.Background()
+	o := &asyncOptions{}
+	obj := ContextOption{ctx: ctx}
+
+	obj.asyncApply(o)
+
+	assert.Equal(t, ctx, o.ctx)
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+
+	result := Context(ctx)
+
+	assert.Equal(t, ContextOption{ctx: ctx}, result)
+}
+
 func TestArgJoinerImplementsArgOption(t *testing.T) {
 	assert.Implements(t, (*ArgOption)(nil), ArgJoiner(""))
 }
@@ -122,3 +287,56 @@ func TestArgJoinerArgApply(t *testing.T) {
 		joiner: "|",
 	}, o)
 }
+
+func TestRetainImplementsFileOption(t *testing.T) {
+	assert.Implements(t, (*FileOption)(nil), retain{})
+}
+
+func TestRetainFileApply(t *testing.T) {
+	reg := NewSourceRegistry()
+	s := &FileScanner{}
+	obj := retain{reg: reg}
+
+	obj.fileApply(s)
+
+	assert.Same(t, reg, s.reg)
+}
+
+func TestRetain(t *testing.T) {
+	reg := NewSourceRegistry()
+
+	result := Retain(reg)
+
+	assert.Equal(t, retain{reg: reg}, result)
+}
+
+func TestEncodingImplementsFileOption(t *testing.T) {
+	assert.Implements(t, (*FileOption)(nil), encoding{})
+}
+
+func TestEncodingFileApply(t *testing.T) {
+	dec := &mockDecoder{}
+	s := &FileScanner{}
+	obj := encoding{dec: dec, bom: true}
+
+	obj.fileApply(s)
+
+	assert.Same(t, dec, s.dec)
+	assert.True(t, s.bom)
+}
+
+func TestEncoding(t *testing.T) {
+	dec := &mockDecoder{}
+
+	result := Encoding(dec)
+
+	assert.Equal(t, encoding{dec: dec}, result)
+}
+
+func TestDetectEncoding(t *testing.T) {
+	dec := &mockDecoder{}
+
+	result := DetectEncoding(dec)
+
+	assert.Equal(t, encoding{dec: dec, bom: true}, result)
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+// IPeekScanner is an interface for a scanner that also provides the
+// ability to look ahead at upcoming characters without consuming
+// them.
+type IPeekScanner interface {
+	Scanner
+
+	// Peek returns the nth upcoming character without consuming
+	// it, along with the error, if any, that Next will return with
+	// it.  Peek(0) returns the character that the next call to
+	// Next will return.  Once the source has been exhausted, Peek
+	// returns the final EOF character.  If n is negative, Peek
+	// returns an EOF character with ErrPeekOffset.
+	Peek(n int) (Char, error)
+}
+
+// peekElem is a struct type containing the returned character and
+// error from the source scanner.
+type peekElem struct {
+	ch  Char  // The character returned
+	err error // The error returned
+}
+
+// PeekScanner is an implementation of IPeekScanner.  It wraps another
+// scanner and buffers the characters that have been peeked at until
+// they are consumed by Next.
+type PeekScanner struct {
+	src   Scanner    // The source scanner
+	queue []peekElem // Characters peeked at but not yet consumed
+	last  peekElem   // Last return from source
+}
+
+// NewPeekScanner wraps another scanner in a PeekScanner.
+func NewPeekScanner(src Scanner) *PeekScanner {
+	return &PeekScanner{
+		src:   src,
+		queue: []peekElem{},
+		last: peekElem{
+			ch: Char{Rune: EOF},
+		},
+	}
+}
+
+// read is a helper that reads a character from the source.  It
+// detects when the source has been exhausted.
+func (ps *PeekScanner) read() peekElem {
+	if ps.src == nil {
+		return ps.last
+	}
+
+	ch, err := ps.src.Next()
+	if ch.Rune == EOF {
+		ps.src = nil
+		ps.last = peekElem{
+			ch: ch,
+		}
+	}
+
+	return peekElem{
+		ch:  ch,
+		err: err,
+	}
+}
+
+// Next returns the next character from the stream as a Char, which
+// will include the character's location.  If an error was
+// encountered, that will also be returned.
+func (ps *PeekScanner) Next() (Char, error) {
+	// Consume a peeked character first
+	if len(ps.queue) > 0 {
+		elem := ps.queue[0]
+		ps.queue = ps.queue[1:]
+		return elem.ch, elem.err
+	}
+
+	elem := ps.read()
+	return elem.ch, elem.err
+}
+
+// Peek returns the nth upcoming character without consuming it, along
+// with the error, if any, that Next will return with it.  Peek(0)
+// returns the character that the next call to Next will return.  Once
+// the source has been exhausted, Peek returns the final EOF
+// character.  If n is negative, Peek returns an EOF character with
+// ErrPeekOffset.
+func (ps *PeekScanner) Peek(n int) (Char, error) {
+	if n < 0 {
+		return Char{Rune: EOF}, ErrPeekOffset
+	}
+
+	// Read enough characters
+	for len(ps.queue) <= n && ps.src != nil {
+		ps.queue = append(ps.queue, ps.read())
+	}
+
+	if n < len(ps.queue) {
+		return ps.queue[n].ch, ps.queue[n].err
+	}
+
+	return ps.last.ch, nil
+}
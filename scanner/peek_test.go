@@ -0,0 +1,198 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeekScannerImplementsIPeekScanner(t *testing.T) {
+	assert.Implements(t, (*IPeekScanner)(nil), &PeekScanner{})
+}
+
+func TestNewPeekScanner(t *testing.T) {
+	src := &mockScanner{}
+
+	result := NewPeekScanner(src)
+
+	assert.Equal(t, &PeekScanner{
+		src:   src,
+		queue: []peekElem{},
+		last: peekElem{
+			ch: Char{Rune: EOF},
+		},
+	}, result)
+}
+
+func TestPeekScannerReadBase(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 'c'}, assert.AnError)
+	obj := &PeekScanner{src: src}
+
+	result := obj.read()
+
+	assert.Equal(t, peekElem{ch: Char{Rune: 'c'}, err: assert.AnError}, result)
+	assert.Same(t, src, obj.src)
+	src.AssertExpectations(t)
+}
+
+func TestPeekScannerReadEOF(t *testing.T) {
+	loc := &mockLocation{}
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: EOF, Loc: loc}, assert.AnError)
+	obj := &PeekScanner{src: src}
+
+	result := obj.read()
+
+	assert.Equal(t, peekElem{ch: Char{Rune: EOF, Loc: loc}, err: assert.AnError}, result)
+	assert.Nil(t, obj.src)
+	assert.Equal(t, peekElem{ch: Char{Rune: EOF, Loc: loc}}, obj.last)
+	src.AssertExpectations(t)
+}
+
+func TestPeekScannerReadExhausted(t *testing.T) {
+	obj := &PeekScanner{
+		last: peekElem{ch: Char{Rune: EOF}},
+	}
+
+	result := obj.read()
+
+	assert.Equal(t, peekElem{ch: Char{Rune: EOF}}, result)
+}
+
+func TestPeekScannerNextQueued(t *testing.T) {
+	src := &mockScanner{}
+	obj := &PeekScanner{
+		src: src,
+		queue: []peekElem{
+			{ch: Char{Rune: 'a'}, err: assert.AnError},
+			{ch: Char{Rune: 'b'}},
+		},
+	}
+
+	result, err := obj.Next()
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, Char{Rune: 'a'}, result)
+	assert.Equal(t, []peekElem{{ch: Char{Rune: 'b'}}}, obj.queue)
+	src.AssertExpectations(t)
+}
+
+func TestPeekScannerNextSource(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 'c'}, nil)
+	obj := &PeekScanner{
+		src:   src,
+		queue: []peekElem{},
+	}
+
+	result, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: 'c'}, result)
+	src.AssertExpectations(t)
+}
+
+func TestPeekScannerPeekBase(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 'c'}, assert.AnError).Once()
+	obj := &PeekScanner{
+		src:   src,
+		queue: []peekElem{{ch: Char{Rune: 'a'}}},
+	}
+
+	result, err := obj.Peek(1)
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, Char{Rune: 'c'}, result)
+	assert.Equal(t, []peekElem{
+		{ch: Char{Rune: 'a'}},
+		{ch: Char{Rune: 'c'}, err: assert.AnError},
+	}, obj.queue)
+	src.AssertExpectations(t)
+}
+
+func TestPeekScannerPeekQueued(t *testing.T) {
+	src := &mockScanner{}
+	obj := &PeekScanner{
+		src: src,
+		queue: []peekElem{
+			{ch: Char{Rune: 'a'}},
+			{ch: Char{Rune: 'b'}},
+		},
+	}
+
+	result, err := obj.Peek(0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: 'a'}, result)
+	assert.Len(t, obj.queue, 2)
+	src.AssertExpectations(t)
+}
+
+func TestPeekScannerPeekPastEOF(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: EOF}, assert.AnError).Once()
+	obj := &PeekScanner{
+		src:   src,
+		queue: []peekElem{},
+	}
+
+	result, err := obj.Peek(2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: EOF}, result)
+	assert.Equal(t, []peekElem{{ch: Char{Rune: EOF}, err: assert.AnError}}, obj.queue)
+	assert.Nil(t, obj.src)
+	src.AssertExpectations(t)
+}
+
+func TestPeekScannerPeekNegative(t *testing.T) {
+	src := &mockScanner{}
+	obj := &PeekScanner{
+		src:   src,
+		queue: []peekElem{},
+	}
+
+	result, err := obj.Peek(-1)
+
+	assert.Same(t, ErrPeekOffset, err)
+	assert.Equal(t, Char{Rune: EOF}, result)
+	assert.Equal(t, []peekElem{}, obj.queue)
+	src.AssertExpectations(t)
+}
+
+func TestPeekScannerIntegration(t *testing.T) {
+	obj := NewPeekScanner(NewListScanner([]Char{
+		{Rune: 'a'},
+		{Rune: 'b'},
+		{Rune: EOF},
+	}, nil))
+
+	ch, _ := obj.Peek(1)
+	assert.Equal(t, 'b', ch.Rune)
+	ch, _ = obj.Next()
+	assert.Equal(t, 'a', ch.Rune)
+	ch, _ = obj.Peek(0)
+	assert.Equal(t, 'b', ch.Rune)
+	ch, _ = obj.Next()
+	assert.Equal(t, 'b', ch.Rune)
+	ch, _ = obj.Next()
+	assert.Equal(t, EOF, ch.Rune)
+	ch, _ = obj.Peek(5)
+	assert.Equal(t, EOF, ch.Rune)
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// invalidByte is a byte that is never valid in UTF-8.  It is used to
+// pass invalid input from an io.RuneReader through to the FileScanner
+// so that it is reported to the encoding error handler.
+const invalidByte = 0xff
+
+// runeLen is a helper that returns the number of bytes required to
+// encode a rune in UTF-8.  Runes that cannot be encoded are counted
+// as the Unicode replacement character, U+FFFD.
+func runeLen(r rune) int {
+	if l := utf8.RuneLen(r); l >= 0 {
+		return l
+	}
+
+	return utf8.RuneLen(utf8.RuneError)
+}
+
+// ScannerReader is an adapter that allows a Scanner to be used as an
+// io.RuneScanner or an io.Reader.  The characters returned by the
+// scanner are encoded as UTF-8 for Read.  At the end of the stream,
+// io.EOF is returned; any other error returned by the scanner is
+// returned as is, and ends the stream.
+type ScannerReader struct {
+	src    Scanner           // The source scanner
+	last   Char              // Last character read
+	unread bool              // Whether the last character was unread
+	undo   bool              // Whether UnreadRune may be called
+	buf    [utf8.UTFMax]byte // Buffer for encoding a character
+	pend   []byte            // Bytes of a character not yet read
+	err    error             // Error that ended the stream
+}
+
+// NewScannerReader wraps a Scanner in a ScannerReader.
+func NewScannerReader(src Scanner) *ScannerReader {
+	return &ScannerReader{
+		src: src,
+	}
+}
+
+// next is a helper that retrieves the next character, either the
+// character that was unread or the next character from the source.
+func (r *ScannerReader) next() (Char, error) {
+	if r.unread {
+		r.unread = false
+		return r.last, nil
+	}
+
+	if r.err != nil {
+		return Char{}, r.err
+	}
+
+	ch, err := r.src.Next()
+	if err == nil && ch.Rune == EOF {
+		err = io.EOF
+	}
+	if err != nil {
+		r.err = err
+		return Char{}, err
+	}
+
+	r.last = ch
+	return ch, nil
+}
+
+// ReadRune reads a single character and returns the rune and its
+// size in bytes, when encoded in UTF-8.  It implements
+// io.RuneReader.
+func (r *ScannerReader) ReadRune() (rune, int, error) {
+	r.undo = false
+
+	// Finish off a partially read character
+	if len(r.pend) > 0 {
+		r.pend = r.pend[1:]
+		return utf8.RuneError, 1, nil
+	}
+
+	ch, err := r.next()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	r.undo = true
+	return ch.Rune, runeLen(ch.Rune), nil
+}
+
+// UnreadRune causes the next call to ReadRune or Read to return the
+// last character read by ReadRune.  It returns ErrUnreadRune if the
+// last method called was not ReadRune.  It implements
+// io.RuneScanner.
+func (r *ScannerReader) UnreadRune() error {
+	if !r.undo {
+		return ErrUnreadRune
+	}
+
+	r.undo = false
+	r.unread = true
+	return nil
+}
+
+// Read reads the characters from the scanner into p, encoded as
+// UTF-8.  It implements io.Reader.
+func (r *ScannerReader) Read(p []byte) (n int, err error) {
+	r.undo = false
+
+	for n < len(p) {
+		// Encode the next character
+		if len(r.pend) == 0 {
+			var ch Char
+			if ch, err = r.next(); err != nil {
+				break
+			}
+			r.pend = r.buf[:utf8.EncodeRune(r.buf[:], ch.Rune)]
+		}
+
+		c := copy(p[n:], r.pend)
+		r.pend = r.pend[c:]
+		n += c
+	}
+
+	// Report errors only if nothing was read
+	if n > 0 {
+		err = nil
+	}
+
+	return
+}
+
+// Location returns the location of the last character read, or nil
+// if no characters have been read.  This allows errors reported by
+// consumers of the ScannerReader to be located in the source.
+func (r *ScannerReader) Location() Location {
+	return r.last.Loc
+}
+
+// runeReader is an adapter that allows an io.RuneReader to be used as
+// an io.Reader.  The runes are encoded as UTF-8, except that invalid
+// input, reported by the io.RuneReader as the Unicode replacement
+// character with a size of 1, is passed through as an invalid byte.
+type runeReader struct {
+	src  io.RuneReader     // The source rune reader
+	buf  [utf8.UTFMax]byte // Buffer for encoding a rune
+	pend []byte            // Bytes of a rune not yet read
+	err  error             // Error returned by the source
+}
+
+// Read reads the runes from the source into p, encoded as UTF-8.
+func (r *runeReader) Read(p []byte) (n int, err error) {
+	for n < len(p) {
+		// Encode the next rune
+		if len(r.pend) == 0 {
+			if r.err != nil {
+				break
+			}
+
+			var ch rune
+			var size int
+			if ch, size, r.err = r.src.ReadRune(); r.err != nil {
+				break
+			}
+
+			if ch == utf8.RuneError && size == 1 {
+				r.buf[0] = invalidByte
+				r.pend = r.buf[:1]
+			} else {
+				r.pend = r.buf[:utf8.EncodeRune(r.buf[:], ch)]
+			}
+		}
+
+		c := copy(p[n:], r.pend)
+		r.pend = r.pend[c:]
+		n += c
+	}
+
+	// Report errors only if nothing was read
+	if n == 0 {
+		err = r.err
+	}
+
+	return
+}
+
+// NewRuneReaderScanner constructs a FileScanner that reads its
+// characters from an io.RuneReader, rather than an io.Reader.  The
+// locations of the characters are computed as if the runes were
+// encoded in UTF-8; invalid input, reported by the io.RuneReader as
+// the Unicode replacement character with a size of 1, is reported to
+// the encoding error handler.  The options are the same as for
+// NewFileScanner, although an encoding should not be specified.
+func NewRuneReaderScanner(r io.RuneReader, loc Location, options ...FileOption) *FileScanner {
+	return NewFileScanner(&runeReader{src: r}, loc, options...)
+}
@@ -0,0 +1,348 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuneLenBase(t *testing.T) {
+	assert.Equal(t, 1, runeLen('a'))
+	assert.Equal(t, 2, runeLen('ñ'))
+	assert.Equal(t, 4, runeLen('\U0001f600'))
+}
+
+func TestRuneLenInvalid(t *testing.T) {
+	assert.Equal(t, 3, runeLen(0xd800))
+}
+
+func TestScannerReaderImplementsRuneScanner(t *testing.T) {
+	assert.Implements(t, (*io.RuneScanner)(nil), &ScannerReader{})
+}
+
+func TestScannerReaderImplementsReader(t *testing.T) {
+	assert.Implements(t, (*io.Reader)(nil), &ScannerReader{})
+}
+
+func TestNewScannerReader(t *testing.T) {
+	src := &mockScanner{}
+
+	result := NewScannerReader(src)
+
+	assert.Equal(t, &ScannerReader{src: src}, result)
+}
+
+func TestScannerReaderNextBase(t *testing.T) {
+	loc := &mockLocation{}
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 'c', Loc: loc}, nil)
+	obj := &ScannerReader{src: src}
+
+	result, err := obj.next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: 'c', Loc: loc}, result)
+	assert.Equal(t, Char{Rune: 'c', Loc: loc}, obj.last)
+	src.AssertExpectations(t)
+}
+
+func TestScannerReaderNextEOF(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: EOF}, nil)
+	obj := &ScannerReader{src: src}
+
+	result, err := obj.next()
+
+	assert.Same(t, io.EOF, err)
+	assert.Equal(t, Char{}, result)
+	assert.Same(t, io.EOF, obj.err)
+	src.AssertExpectations(t)
+}
+
+func TestScannerReaderNextError(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 'c'}, assert.AnError)
+	obj := &ScannerReader{src: src}
+
+	result, err := obj.next()
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, Char{}, result)
+	assert.Same(t, assert.AnError, obj.err)
+	src.AssertExpectations(t)
+}
+
+func TestScannerReaderNextStoredError(t *testing.T) {
+	src := &mockScanner{}
+	obj := &ScannerReader{
+		src: src,
+		err: assert.AnError,
+	}
+
+	result, err := obj.next()
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, Char{}, result)
+	src.AssertExpectations(t)
+}
+
+func TestScannerReaderNextUnread(t *testing.T) {
+	src := &mockScanner{}
+	obj := &ScannerReader{
+		src:    src,
+		last:   Char{Rune: 'c'},
+		unread: true,
+		err:    io.EOF,
+	}
+
+	result, err := obj.next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: 'c'}, result)
+	assert.False(t, obj.unread)
+	src.AssertExpectations(t)
+}
+
+func TestScannerReaderReadRuneBase(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 'ñ'}, nil)
+	obj := &ScannerReader{src: src}
+
+	r, size, err := obj.ReadRune()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 'ñ', r)
+	assert.Equal(t, 2, size)
+	assert.True(t, obj.undo)
+	src.AssertExpectations(t)
+}
+
+func TestScannerReaderReadRuneError(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: EOF}, nil)
+	obj := &ScannerReader{
+		src:  src,
+		undo: true,
+	}
+
+	r, size, err := obj.ReadRune()
+
+	assert.Same(t, io.EOF, err)
+	assert.Equal(t, rune(0), r)
+	assert.Equal(t, 0, size)
+	assert.False(t, obj.undo)
+	src.AssertExpectations(t)
+}
+
+func TestScannerReaderReadRunePending(t *testing.T) {
+	src := &mockScanner{}
+	obj := &ScannerReader{
+		src:  src,
+		pend: []byte{0x80, 0x80},
+	}
+
+	r, size, err := obj.ReadRune()
+
+	assert.NoError(t, err)
+	assert.Equal(t, utf8.RuneError, r)
+	assert.Equal(t, 1, size)
+	assert.Equal(t, []byte{0x80}, obj.pend)
+	assert.False(t, obj.undo)
+	src.AssertExpectations(t)
+}
+
+func TestScannerReaderUnreadRuneBase(t *testing.T) {
+	obj := &ScannerReader{undo: true}
+
+	err := obj.UnreadRune()
+
+	assert.NoError(t, err)
+	assert.False(t, obj.undo)
+	assert.True(t, obj.unread)
+}
+
+func TestScannerReaderUnreadRuneInvalid(t *testing.T) {
+	obj := &ScannerReader{}
+
+	err := obj.UnreadRune()
+
+	assert.Same(t, ErrUnreadRune, err)
+	assert.False(t, obj.unread)
+}
+
+func TestScannerReaderReadBase(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 'a'}, nil).Once()
+	src.On("Next").Return(Char{Rune: 'ñ'}, nil).Once()
+	src.On("Next").Return(Char{Rune: EOF}, nil).Once()
+	obj := &ScannerReader{
+		src:  src,
+		undo: true,
+	}
+	p := make([]byte, 10)
+
+	n, err := obj.Read(p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("añ"), p[:n])
+	assert.False(t, obj.undo)
+	assert.Same(t, io.EOF, obj.err)
+	src.AssertExpectations(t)
+}
+
+func TestScannerReaderReadShort(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 'a'}, nil).Once()
+	src.On("Next").Return(Char{Rune: 'ñ'}, nil).Once()
+	obj := &ScannerReader{src: src}
+	p := make([]byte, 2)
+
+	n, err := obj.Read(p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("a\xc3"), p[:n])
+	assert.Equal(t, []byte{0xb1}, obj.pend)
+	src.AssertExpectations(t)
+}
+
+func TestScannerReaderReadError(t *testing.T) {
+	src := &mockScanner{}
+	obj := &ScannerReader{
+		src: src,
+		err: io.EOF,
+	}
+	p := make([]byte, 10)
+
+	n, err := obj.Read(p)
+
+	assert.Same(t, io.EOF, err)
+	assert.Equal(t, 0, n)
+	src.AssertExpectations(t)
+}
+
+func TestScannerReaderLocation(t *testing.T) {
+	loc := &mockLocation{}
+	obj := &ScannerReader{last: Char{Rune: 'c', Loc: loc}}
+
+	result := obj.Location()
+
+	assert.Same(t, loc, result)
+}
+
+func TestScannerReaderIntegration(t *testing.T) {
+	src := NewFileScanner(strings.NewReader("foo\nbar 42\n"), FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	})
+	obj := NewScannerReader(src)
+
+	br := bufio.NewReader(obj)
+	line, err := br.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "foo\n", line)
+	assert.True(t, regexp.MustCompile(`bar \d+`).MatchReader(br))
+	assert.Equal(t, FileLocation{
+		File: "file",
+		B:    FilePos{L: 2, C: 7, O: 10, R: 10},
+		E:    FilePos{L: 3, C: 1, O: 11, R: 11},
+	}, obj.Location())
+}
+
+func TestRuneReaderImplementsReader(t *testing.T) {
+	assert.Implements(t, (*io.Reader)(nil), &runeReader{})
+}
+
+func TestRuneReaderReadBase(t *testing.T) {
+	obj := &runeReader{src: strings.NewReader("añ\xffb")}
+	p := make([]byte, 10)
+
+	n, err := obj.Read(p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("añ\xffb"), p[:n])
+	assert.Same(t, io.EOF, obj.err)
+}
+
+func TestRuneReaderReadShort(t *testing.T) {
+	obj := &runeReader{src: strings.NewReader("añ")}
+	p := make([]byte, 2)
+
+	n, err := obj.Read(p)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("a\xc3"), p[:n])
+	assert.Equal(t, []byte{0xb1}, obj.pend)
+	assert.NoError(t, obj.err)
+}
+
+func TestRuneReaderReadError(t *testing.T) {
+	obj := &runeReader{
+		src: strings.NewReader("a"),
+		err: assert.AnError,
+	}
+	p := make([]byte, 10)
+
+	n, err := obj.Read(p)
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestRuneReaderReadAll(t *testing.T) {
+	obj := &runeReader{src: strings.NewReader("tab\tña\U0001f600\n")}
+
+	result, err := ioutil.ReadAll(obj)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("tab\tña\U0001f600\n"), result)
+}
+
+func TestNewRuneReaderScanner(t *testing.T) {
+	obj := NewRuneReaderScanner(strings.NewReader("a\tñ\n\xffb"), FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}, EncodingError(ReplaceInvalid))
+
+	results := []Char{}
+	for {
+		ch, err := obj.Next()
+		require.NoError(t, err)
+		results = append(results, Char{Rune: ch.Rune, Loc: ch.Loc})
+		if ch.Rune == EOF {
+			break
+		}
+	}
+
+	assert.Equal(t, []Char{
+		{Rune: 'a', Loc: FileLocation{File: "file", B: FilePos{L: 1, C: 1}, E: FilePos{L: 1, C: 2, O: 1, R: 1}}},
+		{Rune: '\t', Loc: FileLocation{File: "file", B: FilePos{L: 1, C: 2, O: 1, R: 1}, E: FilePos{L: 1, C: 9, O: 2, R: 2}}},
+		{Rune: 'ñ', Loc: FileLocation{File: "file", B: FilePos{L: 1, C: 9, O: 2, R: 2}, E: FilePos{L: 1, C: 10, O: 4, R: 3}}},
+		{Rune: '\n', Loc: FileLocation{File: "file", B: FilePos{L: 1, C: 10, O: 4, R: 3}, E: FilePos{L: 2, C: 1, O: 5, R: 4}}},
+		{Rune: utf8.RuneError, Loc: FileLocation{File: "file", B: FilePos{L: 2, C: 1, O: 5, R: 4}, E: FilePos{L: 2, C: 2, O: 6, R: 5}}},
+		{Rune: 'b', Loc: FileLocation{File: "file", B: FilePos{L: 2, C: 2, O: 6, R: 5}, E: FilePos{L: 2, C: 3, O: 7, R: 6}}},
+		{Rune: EOF, Loc: FileLocation{File: "file", B: FilePos{L: 2, C: 3, O: 7, R: 6}, E: FilePos{L: 2, C: 3, O: 7, R: 6}}},
+	}, results)
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"strings"
+)
+
+// Relocator maps locations within embedded content, such as the
+// contents of a string literal that is itself lexed or parsed, into
+// the coordinate space of the enclosing source.  It is constructed
+// from the characters of the embedded content after any escape
+// sequences have been decoded, each carrying its location in the
+// enclosing source; the location of a character produced by an escape
+// sequence should span the entire escape sequence.  Locations in the
+// embedded content are FileLocation values, and are mapped using
+// their rune offsets; a location spanning several characters of the
+// embedded content is mapped to the location spanning the
+// corresponding characters of the enclosing source.
+type Relocator struct {
+	base Location   // The location preceding the embedded content
+	text string     // The decoded text of the embedded content
+	locs []Location // The enclosing location of each character
+}
+
+// NewRelocator constructs and returns a Relocator.  The base is the
+// location in the enclosing source immediately preceding the embedded
+// content, such as the location of the opening quote of a string
+// literal; it is used to locate the end of empty content.  The chars
+// are the decoded characters of the embedded content, excluding any
+// EOF, with their locations in the enclosing source.
+func NewRelocator(base Location, chars []Char) *Relocator {
+	buf := &strings.Builder{}
+	locs := make([]Location, 0, len(chars))
+	for _, ch := range chars {
+		if ch.Rune == EOF {
+			continue
+		}
+		buf.WriteRune(ch.Rune)
+		locs = append(locs, ch.Loc)
+	}
+
+	return &Relocator{
+		base: base,
+		text: buf.String(),
+		locs: locs,
+	}
+}
+
+// Text returns the decoded text of the embedded content.
+func (r *Relocator) Text() string {
+	return r.text
+}
+
+// end is a helper that returns the location in the enclosing source
+// of the end of the embedded content.
+func (r *Relocator) end() Location {
+	loc := r.base
+	if len(r.locs) > 0 {
+		loc = r.locs[len(r.locs)-1]
+	}
+	if loc == nil {
+		return nil
+	}
+
+	return loc.Incr(EOF, DefaultTabStop)
+}
+
+// Relocate maps a location within the embedded content to the
+// corresponding location in the enclosing source.  Locations that
+// are not FileLocation values are returned unchanged.  A location
+// that does not cover any characters, such as the location of the
+// EOF, is mapped to the location of the character at its position,
+// or to the end of the embedded content.
+func (r *Relocator) Relocate(loc Location) Location {
+	fl, ok := loc.(FileLocation)
+	if !ok {
+		return loc
+	}
+
+	// Handle empty locations
+	b, e := fl.B.R, fl.E.R
+	switch {
+	case b < 0:
+		return loc
+	case b >= len(r.locs):
+		return r.end()
+	case e <= b:
+		return r.locs[b]
+	case e > len(r.locs):
+		e = len(r.locs)
+	}
+
+	// Span the characters in the enclosing source
+	result, err := r.locs[b].ThruEnd(r.locs[e-1])
+	if err != nil {
+		return r.locs[b]
+	}
+
+	return result
+}
+
+// RelocateError maps the location attached to an error, if any, as
+// for Relocate.
+func (r *Relocator) RelocateError(err error) error {
+	if loc := LocationOf(err); loc != nil {
+		return relocateError(err, r.Relocate(loc))
+	}
+
+	return err
+}
+
+// Scanner returns a RelocatingScanner that scans the decoded text of
+// the embedded content, returning characters located in the
+// enclosing source.  The options are passed to NewStringScanner.
+func (r *Relocator) Scanner(options ...MemoryOption) *RelocatingScanner {
+	return NewRelocatingScanner(NewStringScanner(r.text, FileLocation{
+		B: FilePos{L: 1, C: 1},
+		E: FilePos{L: 1, C: 1},
+	}, options...), r)
+}
+
+// RelocatingScanner is a scanner that wraps another scanner scanning
+// embedded content, and rewrites the locations it returns, including
+// the locations attached to errors, into the coordinate space of the
+// enclosing source using a Relocator.
+type RelocatingScanner struct {
+	src Scanner    // The wrapped scanner
+	rel *Relocator // The relocator to use
+}
+
+// NewRelocatingScanner constructs and returns a RelocatingScanner
+// that wraps the specified scanner.  The wrapped scanner should scan
+// the text returned by the Relocator's Text method, beginning with a
+// FileLocation with rune offsets of 0.
+func NewRelocatingScanner(src Scanner, rel *Relocator) *RelocatingScanner {
+	return &RelocatingScanner{
+		src: src,
+		rel: rel,
+	}
+}
+
+// Next returns the next character from the stream as a Char, which
+// will include the character's location.  If an error was
+// encountered, that will also be returned.
+func (s *RelocatingScanner) Next() (Char, error) {
+	ch, err := s.src.Next()
+
+	// Rewrite the locations
+	ch.Loc = s.rel.Relocate(ch.Loc)
+	err = s.rel.RelocateError(err)
+
+	return ch, err
+}
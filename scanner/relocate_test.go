@@ -0,0 +1,263 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// relocLoc is a helper that constructs a FileLocation for the
+// relocation tests.
+func relocLoc(b, e int) FileLocation {
+	return FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: b + 1, O: b, R: b},
+		E:    FilePos{L: 1, C: e + 1, O: e, R: e},
+	}
+}
+
+// nestedLoc is a helper that constructs a FileLocation within
+// embedded content.
+func nestedLoc(b, e int) FileLocation {
+	return FileLocation{
+		B: FilePos{L: 1, C: b + 1, O: b, R: b},
+		E: FilePos{L: 1, C: e + 1, O: e, R: e},
+	}
+}
+
+// testRelocator is a helper that constructs a Relocator for the
+// embedded content of the string literal `"a\tb"`, which begins at
+// offset 4 of the enclosing source.
+func testRelocator() *Relocator {
+	return NewRelocator(relocLoc(4, 5), []Char{
+		{Rune: 'a', Loc: relocLoc(5, 6)},
+		{Rune: '\t', Loc: relocLoc(6, 8)},
+		{Rune: 'b', Loc: relocLoc(8, 9)},
+		{Rune: EOF, Loc: relocLoc(9, 9)},
+	})
+}
+
+func TestNewRelocator(t *testing.T) {
+	result := testRelocator()
+
+	assert.Equal(t, &Relocator{
+		base: relocLoc(4, 5),
+		text: "a\tb",
+		locs: []Location{relocLoc(5, 6), relocLoc(6, 8), relocLoc(8, 9)},
+	}, result)
+}
+
+func TestRelocatorText(t *testing.T) {
+	obj := testRelocator()
+
+	result := obj.Text()
+
+	assert.Equal(t, "a\tb", result)
+}
+
+func TestRelocatorRelocate(t *testing.T) {
+	obj := testRelocator()
+	other := &mockLocation{}
+	testCases := []struct {
+		name   string
+		loc    Location
+		expect Location
+	}{
+		{"Single", nestedLoc(0, 1), relocLoc(5, 6)},
+		{"Escape", nestedLoc(1, 2), relocLoc(6, 8)},
+		{"Span", nestedLoc(0, 3), relocLoc(5, 9)},
+		{"Empty", nestedLoc(1, 1), relocLoc(6, 8)},
+		{"EOF", nestedLoc(3, 3), relocLoc(9, 9)},
+		{"Overrun", nestedLoc(2, 5), relocLoc(8, 9)},
+		{"Negative", nestedLoc(-1, 0), nestedLoc(-1, 0)},
+		{"Other", other, other},
+		{"Nil", nil, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := obj.Relocate(tc.loc)
+
+			assert.Equal(t, tc.expect, result)
+		})
+	}
+}
+
+func TestRelocatorRelocateSplit(t *testing.T) {
+	obj := NewRelocator(nil, []Char{
+		{Rune: 'a', Loc: relocLoc(5, 6)},
+		{Rune: 'b', Loc: FileLocation{File: "other"}},
+	})
+
+	result := obj.Relocate(nestedLoc(0, 2))
+
+	assert.Equal(t, relocLoc(5, 6), result)
+}
+
+func TestRelocatorRelocateEmptyContent(t *testing.T) {
+	obj := NewRelocator(relocLoc(4, 5), nil)
+
+	result := obj.Relocate(nestedLoc(0, 0))
+
+	assert.Equal(t, relocLoc(5, 5), result)
+}
+
+func TestRelocatorRelocateEmptyNoBase(t *testing.T) {
+	obj := NewRelocator(nil, nil)
+
+	result := obj.Relocate(nestedLoc(0, 0))
+
+	assert.Nil(t, result)
+}
+
+func TestRelocatorRelocateError(t *testing.T) {
+	obj := testRelocator()
+
+	result := obj.RelocateError(LocationError(nestedLoc(1, 2), assert.AnError))
+
+	assert.Equal(t, &locationError{
+		loc: relocLoc(6, 8),
+		err: assert.AnError,
+	}, result)
+}
+
+func TestRelocatorRelocateErrorWrapped(t *testing.T) {
+	obj := testRelocator()
+
+	result := obj.RelocateError(fmt.Errorf("wrapped: %w", LocationError(nestedLoc(1, 2), assert.AnError)))
+
+	assert.Equal(t, relocLoc(6, 8), LocationOf(result))
+	assert.Equal(t, fmt.Sprintf("%s: wrapped: %s", relocLoc(6, 8), assert.AnError), result.Error())
+	assert.True(t, errors.Is(result, assert.AnError))
+}
+
+func TestRelocatorRelocateErrorUnlocated(t *testing.T) {
+	obj := testRelocator()
+
+	result := obj.RelocateError(assert.AnError)
+
+	assert.Same(t, assert.AnError, result)
+}
+
+func TestRelocatorRelocateErrorNil(t *testing.T) {
+	obj := testRelocator()
+
+	result := obj.RelocateError(nil)
+
+	assert.NoError(t, result)
+}
+
+func TestRelocatorScanner(t *testing.T) {
+	obj := testRelocator()
+
+	result := obj.Scanner()
+
+	assert.Same(t, obj, result.rel)
+	assert.IsType(t, &MemoryScanner{}, result.src)
+	expected := []Char{
+		{Rune: 'a', Loc: relocLoc(5, 6)},
+		{Rune: '\t', Loc: relocLoc(6, 8)},
+		{Rune: 'b', Loc: relocLoc(8, 9)},
+		{Rune: EOF, Loc: relocLoc(9, 9)},
+	}
+	for _, exp := range expected {
+		ch, err := result.Next()
+		require.NoError(t, err)
+		assert.Equal(t, exp, ch)
+	}
+}
+
+func TestRelocatingScannerImplementsScanner(t *testing.T) {
+	assert.Implements(t, (*Scanner)(nil), &RelocatingScanner{})
+}
+
+func TestNewRelocatingScanner(t *testing.T) {
+	src := &mockScanner{}
+	rel := testRelocator()
+
+	result := NewRelocatingScanner(src, rel)
+
+	assert.Equal(t, &RelocatingScanner{
+		src: src,
+		rel: rel,
+	}, result)
+}
+
+func TestRelocatingScannerNext(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: '\t', Loc: nestedLoc(1, 2)}, LocationError(nestedLoc(1, 2), assert.AnError))
+	obj := NewRelocatingScanner(src, testRelocator())
+
+	ch, err := obj.Next()
+
+	assert.Equal(t, Char{Rune: '\t', Loc: relocLoc(6, 8)}, ch)
+	assert.Same(t, assert.AnError, errors.Unwrap(err))
+	assert.Equal(t, relocLoc(6, 8), LocationOf(err))
+}
+
+func TestRelocatingScannerEmbedded(t *testing.T) {
+	outer := NewStringScanner(`x = "a\"b"`, FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	})
+	var base Location
+	chars := []Char{}
+	for {
+		ch, err := outer.Next()
+		require.NoError(t, err)
+		if ch.Rune == '"' {
+			if base != nil {
+				break
+			}
+			base = ch.Loc
+			continue
+		} else if base == nil {
+			continue
+		}
+
+		// Decode escapes
+		if ch.Rune == '\\' {
+			esc, err := outer.Next()
+			require.NoError(t, err)
+			esc.Loc, err = ch.Loc.ThruEnd(esc.Loc)
+			require.NoError(t, err)
+			ch = esc
+		}
+		chars = append(chars, ch)
+	}
+	rel := NewRelocator(base, chars)
+	obj := rel.Scanner()
+
+	for _, r := range "a\"" {
+		ch, err := obj.Next()
+		require.NoError(t, err)
+		require.Equal(t, r, ch.Rune)
+	}
+	ch, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: 'b', Loc: FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 9, O: 8, R: 8},
+		E:    FilePos{L: 1, C: 10, O: 9, R: 9},
+	}}, ch)
+	assert.Equal(t, "file:1:7-9", rel.Relocate(nestedLoc(1, 2)).String())
+}
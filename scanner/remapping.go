@@ -0,0 +1,105 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+// remap describes a mapping of physical lines to logical lines.
+type remap struct {
+	file string // The logical file name; "" to keep the physical one
+	line int    // The logical line number of the first line
+	phys int    // The physical line number of the first line
+}
+
+// apply applies the mapping to a FileLocation.
+func (m *remap) apply(loc FileLocation) FileLocation {
+	if m.file != "" {
+		loc.File = m.file
+	}
+	loc.B.L += m.line - m.phys
+	loc.E.L += m.line - m.phys
+
+	return loc
+}
+
+// RemappingScanner is a scanner that wraps another scanner and
+// rewrites the FileLocation values it returns, in response to line
+// directives such as "#line 42 file.tmpl".  This allows errors in
+// generated sources to be reported against the original source from
+// which they were generated.  Byte and rune offsets are not
+// rewritten; they continue to refer to the physical source.
+type RemappingScanner struct {
+	src  Scanner // The wrapped scanner
+	cur  *remap  // The mapping currently in effect
+	pend *remap  // A mapping that will take effect on the next line
+	last int     // The physical line of the last character returned
+}
+
+// NewRemappingScanner constructs and returns a RemappingScanner that
+// wraps the specified scanner.
+func NewRemappingScanner(src Scanner) *RemappingScanner {
+	return &RemappingScanner{
+		src: src,
+	}
+}
+
+// Remap informs the scanner of a line directive.  It should be called
+// once the directive has been read, typically by a recognizer or a
+// callback.  The line following the line on which the last character
+// returned by the scanner began will be reported as the designated
+// line of the designated file; subsequent lines are numbered from
+// there.  If file is empty, the file name is not changed.  Note that
+// characters that have already been read from the scanner, such as
+// characters buffered for lookahead, are not affected.
+func (s *RemappingScanner) Remap(file string, line int) {
+	s.pend = &remap{
+		file: file,
+		line: line,
+		phys: s.last + 1,
+	}
+}
+
+// relocate is a helper that rewrites a location.  If the location is
+// not a FileLocation, it is returned unchanged.
+func (s *RemappingScanner) relocate(loc Location) Location {
+	fl, ok := loc.(FileLocation)
+	if !ok {
+		return loc
+	}
+
+	// Activate a pending mapping
+	if s.pend != nil && fl.B.L >= s.pend.phys {
+		s.cur, s.pend = s.pend, nil
+	}
+	s.last = fl.B.L
+
+	if s.cur == nil {
+		return fl
+	}
+	return s.cur.apply(fl)
+}
+
+// Next returns the next character from the stream as a Char, which
+// will include the character's location.  If an error was
+// encountered, that will also be returned.
+func (s *RemappingScanner) Next() (Char, error) {
+	ch, err := s.src.Next()
+
+	// Rewrite the locations
+	ch.Loc = s.relocate(ch.Loc)
+	if fl, ok := LocationOf(err).(FileLocation); ok && s.cur != nil {
+		err = relocateError(err, s.cur.apply(fl))
+	}
+
+	return ch, err
+}
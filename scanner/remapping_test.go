@@ -0,0 +1,206 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemapApplyBase(t *testing.T) {
+	obj := &remap{file: "orig", line: 42, phys: 5}
+
+	result := obj.apply(FileLocation{
+		File: "gen",
+		B:    FilePos{L: 6, C: 3, O: 10, R: 10},
+		E:    FilePos{L: 7, C: 1, O: 11, R: 11},
+	})
+
+	assert.Equal(t, FileLocation{
+		File: "orig",
+		B:    FilePos{L: 43, C: 3, O: 10, R: 10},
+		E:    FilePos{L: 44, C: 1, O: 11, R: 11},
+	}, result)
+}
+
+func TestRemapApplyNoFile(t *testing.T) {
+	obj := &remap{line: 2, phys: 5}
+
+	result := obj.apply(FileLocation{
+		File: "gen",
+		B:    FilePos{L: 6, C: 3},
+		E:    FilePos{L: 6, C: 4},
+	})
+
+	assert.Equal(t, FileLocation{
+		File: "gen",
+		B:    FilePos{L: 3, C: 3},
+		E:    FilePos{L: 3, C: 4},
+	}, result)
+}
+
+func TestRemappingScannerImplementsScanner(t *testing.T) {
+	assert.Implements(t, (*Scanner)(nil), &RemappingScanner{})
+}
+
+func TestNewRemappingScanner(t *testing.T) {
+	src := &mockScanner{}
+
+	result := NewRemappingScanner(src)
+
+	assert.Equal(t, &RemappingScanner{src: src}, result)
+}
+
+func TestRemappingScannerRemap(t *testing.T) {
+	obj := &RemappingScanner{last: 4}
+
+	obj.Remap("orig", 42)
+
+	assert.Equal(t, &remap{file: "orig", line: 42, phys: 5}, obj.pend)
+}
+
+func TestRemappingScannerRelocateOtherLocation(t *testing.T) {
+	loc := &mockLocation{}
+	obj := &RemappingScanner{
+		cur:  &remap{file: "orig", line: 42, phys: 5},
+		last: 4,
+	}
+
+	result := obj.relocate(loc)
+
+	assert.Same(t, loc, result)
+	assert.Equal(t, 4, obj.last)
+}
+
+func TestRemappingScannerRelocateUnmapped(t *testing.T) {
+	loc := FileLocation{File: "gen", B: FilePos{L: 4, C: 1}, E: FilePos{L: 4, C: 2}}
+	obj := &RemappingScanner{}
+
+	result := obj.relocate(loc)
+
+	assert.Equal(t, loc, result)
+	assert.Equal(t, 4, obj.last)
+}
+
+func TestRemappingScannerRelocatePending(t *testing.T) {
+	loc := FileLocation{File: "gen", B: FilePos{L: 4, C: 1}, E: FilePos{L: 5, C: 1}}
+	cur := &remap{file: "orig", line: 42, phys: 2}
+	pend := &remap{file: "other", line: 10, phys: 5}
+	obj := &RemappingScanner{cur: cur, pend: pend}
+
+	result := obj.relocate(loc)
+
+	assert.Equal(t, FileLocation{File: "orig", B: FilePos{L: 44, C: 1}, E: FilePos{L: 45, C: 1}}, result)
+	assert.Same(t, cur, obj.cur)
+	assert.Same(t, pend, obj.pend)
+}
+
+func TestRemappingScannerRelocateActivate(t *testing.T) {
+	loc := FileLocation{File: "gen", B: FilePos{L: 5, C: 1}, E: FilePos{L: 5, C: 2}}
+	pend := &remap{file: "other", line: 10, phys: 5}
+	obj := &RemappingScanner{
+		cur:  &remap{file: "orig", line: 42, phys: 2},
+		pend: pend,
+	}
+
+	result := obj.relocate(loc)
+
+	assert.Equal(t, FileLocation{File: "other", B: FilePos{L: 10, C: 1}, E: FilePos{L: 10, C: 2}}, result)
+	assert.Same(t, pend, obj.cur)
+	assert.Nil(t, obj.pend)
+}
+
+func TestRemappingScannerNextBase(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{
+		Rune: 'c',
+		Loc:  FileLocation{File: "gen", B: FilePos{L: 5, C: 1}, E: FilePos{L: 5, C: 2}},
+	}, nil)
+	obj := &RemappingScanner{
+		src: src,
+		cur: &remap{file: "orig", line: 42, phys: 2},
+	}
+
+	result, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{
+		Rune: 'c',
+		Loc:  FileLocation{File: "orig", B: FilePos{L: 45, C: 1}, E: FilePos{L: 45, C: 2}},
+	}, result)
+	src.AssertExpectations(t)
+}
+
+func TestRemappingScannerNextError(t *testing.T) {
+	loc := FileLocation{File: "gen", B: FilePos{L: 5, C: 1}, E: FilePos{L: 5, C: 2}}
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: EOF, Loc: loc}, LocationError(loc, assert.AnError))
+	obj := &RemappingScanner{
+		src: src,
+		cur: &remap{file: "orig", line: 42, phys: 2},
+	}
+
+	result, err := obj.Next()
+
+	remapped := FileLocation{File: "orig", B: FilePos{L: 45, C: 1}, E: FilePos{L: 45, C: 2}}
+	assert.Equal(t, LocationError(remapped, assert.AnError), err)
+	assert.Equal(t, Char{Rune: EOF, Loc: remapped}, result)
+	src.AssertExpectations(t)
+}
+
+func TestRemappingScannerNextWrappedError(t *testing.T) {
+	loc := FileLocation{File: "gen", B: FilePos{L: 5, C: 1}, E: FilePos{L: 5, C: 2}}
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: EOF, Loc: loc}, fmt.Errorf("wrapped: %w", LocationError(loc, assert.AnError)))
+	obj := &RemappingScanner{
+		src: src,
+		cur: &remap{file: "orig", line: 42, phys: 2},
+	}
+
+	_, err := obj.Next()
+
+	remapped := FileLocation{File: "orig", B: FilePos{L: 45, C: 1}, E: FilePos{L: 45, C: 2}}
+	assert.Equal(t, remapped, LocationOf(err))
+	assert.Equal(t, fmt.Sprintf("%s: wrapped: %s", remapped, assert.AnError), err.Error())
+	assert.True(t, errors.Is(err, assert.AnError))
+	src.AssertExpectations(t)
+}
+
+func TestRemappingScannerIntegration(t *testing.T) {
+	obj := NewRemappingScanner(NewFileScanner(bytes.NewBufferString("a\n#\nb\nc\n"), FileLocation{
+		File: "gen",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}))
+	result := []string{}
+
+	for {
+		ch, err := obj.Next()
+		assert.NoError(t, err)
+		if ch.Rune == EOF {
+			break
+		} else if ch.Rune == '#' {
+			obj.Remap("orig.tmpl", 42)
+		} else if ch.Rune != '\n' {
+			result = append(result, ch.Loc.String())
+		}
+	}
+
+	assert.Equal(t, []string{"gen:1:1", "orig.tmpl:42:1", "orig.tmpl:43:1"}, result)
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"reflect"
+)
+
+// Mark is a position in the character stream of a RewindScanner.
+// Marks are obtained from the Mark method and may be passed to the
+// Reset method to return to that position.
+type Mark struct {
+	pos int // The position of the mark in the stream
+}
+
+// RewindScanner is a scanner that wraps another scanner and allows
+// the stream to be rewound to an earlier position.  Positions may be
+// marked with the Mark method and returned to with the Reset method;
+// the characters following the earliest outstanding mark are
+// retained until the mark is released with the Release method.  The
+// scanner may also seek to the location of any retained character.
+type RewindScanner struct {
+	src   Scanner     // The source scanner
+	buf   []peekElem  // Retained characters
+	base  int         // Position of the first retained character
+	pos   int         // Position of the next character to return
+	marks map[int]int // Reference counts of the outstanding marks
+	last  peekElem    // Last return from source
+}
+
+// NewRewindScanner wraps another scanner in a RewindScanner.
+func NewRewindScanner(src Scanner) *RewindScanner {
+	return &RewindScanner{
+		src:   src,
+		marks: map[int]int{},
+		last: peekElem{
+			ch: Char{Rune: EOF},
+		},
+	}
+}
+
+// read is a helper that reads a character from the source.  It
+// detects when the source has been exhausted.
+func (rs *RewindScanner) read() peekElem {
+	if rs.src == nil {
+		return rs.last
+	}
+
+	ch, err := rs.src.Next()
+	if ch.Rune == EOF {
+		rs.src = nil
+		rs.last = peekElem{
+			ch: ch,
+		}
+	}
+
+	return peekElem{
+		ch:  ch,
+		err: err,
+	}
+}
+
+// trim is a helper that discards retained characters that precede
+// both the current position and all outstanding marks.
+func (rs *RewindScanner) trim() {
+	// Find the earliest position that must be retained
+	low := rs.pos
+	for pos := range rs.marks {
+		if pos < low {
+			low = pos
+		}
+	}
+
+	// Discard the characters before it; if most of the buffer is
+	// being discarded, copy the rest to release the memory, and
+	// otherwise clear the discarded characters so that what they
+	// refer to may be collected
+	if n := low - rs.base; n > 0 {
+		if n >= len(rs.buf)-n {
+			rs.buf = append([]peekElem(nil), rs.buf[n:]...)
+		} else {
+			for i := range rs.buf[:n] {
+				rs.buf[i] = peekElem{}
+			}
+			rs.buf = rs.buf[n:]
+		}
+		rs.base = low
+	}
+	if len(rs.buf) == 0 {
+		rs.buf = nil
+	}
+}
+
+// Next returns the next character from the stream as a Char, which
+// will include the character's location.  If an error was
+// encountered, that will also be returned.
+func (rs *RewindScanner) Next() (Char, error) {
+	// Select the character to return
+	var elem peekElem
+	if idx := rs.pos - rs.base; idx < len(rs.buf) {
+		elem = rs.buf[idx]
+	} else if l := len(rs.buf); l > 0 && rs.buf[l-1].ch.Rune == EOF {
+		// Already retained the EOF; don't retain it again
+		return rs.last.ch, rs.last.err
+	} else {
+		elem = rs.read()
+		rs.buf = append(rs.buf, elem)
+	}
+
+	rs.pos++
+	rs.trim()
+
+	return elem.ch, elem.err
+}
+
+// Mark marks the current position in the stream.  The characters
+// following the mark are retained until the mark is released.
+func (rs *RewindScanner) Mark() Mark {
+	rs.marks[rs.pos]++
+
+	return Mark{pos: rs.pos}
+}
+
+// Reset returns the stream to a marked position, so that the next
+// call to Next returns the character following the mark.  The mark
+// remains outstanding.  If the mark has been released, ErrBadMark is
+// returned.
+func (rs *RewindScanner) Reset(m Mark) error {
+	if rs.marks[m.pos] <= 0 {
+		return ErrBadMark
+	}
+
+	rs.pos = m.pos
+	return nil
+}
+
+// Release releases a mark, allowing the characters retained for it
+// to be discarded.  Marks are reference counted, so a position marked
+// more than once remains marked until each mark has been released.
+func (rs *RewindScanner) Release(m Mark) {
+	if rs.marks[m.pos] <= 1 {
+		delete(rs.marks, m.pos)
+	} else {
+		rs.marks[m.pos]--
+	}
+
+	rs.trim()
+}
+
+// Seek returns the stream to a retained character with the specified
+// location, so that the next call to Next returns that character.
+// Only characters following the earliest outstanding mark or the
+// current position are retained; if no retained character has the
+// location, ErrSeekLocation is returned.
+func (rs *RewindScanner) Seek(loc Location) error {
+	for i, elem := range rs.buf {
+		if reflect.DeepEqual(elem.ch.Loc, loc) {
+			rs.pos = rs.base + i
+			rs.trim()
+			return nil
+		}
+	}
+
+	return ErrSeekLocation
+}
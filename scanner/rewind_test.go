@@ -0,0 +1,336 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func rewindChars(rs ...rune) []Char {
+	chars := []Char{}
+	for i, r := range rs {
+		chars = append(chars, Char{
+			Rune: r,
+			Loc: ArgLocation{
+				B: ArgPos{I: 1, C: i + 1},
+				E: ArgPos{I: 1, C: i + 2},
+			},
+		})
+	}
+
+	return chars
+}
+
+func TestRewindScannerImplementsScanner(t *testing.T) {
+	assert.Implements(t, (*Scanner)(nil), &RewindScanner{})
+}
+
+func TestNewRewindScanner(t *testing.T) {
+	src := &mockScanner{}
+
+	result := NewRewindScanner(src)
+
+	assert.Equal(t, &RewindScanner{
+		src:   src,
+		marks: map[int]int{},
+		last: peekElem{
+			ch: Char{Rune: EOF},
+		},
+	}, result)
+}
+
+func TestRewindScannerReadBase(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 'c'}, assert.AnError)
+	obj := &RewindScanner{src: src}
+
+	result := obj.read()
+
+	assert.Equal(t, peekElem{ch: Char{Rune: 'c'}, err: assert.AnError}, result)
+	assert.Same(t, src, obj.src)
+	src.AssertExpectations(t)
+}
+
+func TestRewindScannerReadEOF(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: EOF}, assert.AnError)
+	obj := &RewindScanner{src: src}
+
+	result := obj.read()
+
+	assert.Equal(t, peekElem{ch: Char{Rune: EOF}, err: assert.AnError}, result)
+	assert.Nil(t, obj.src)
+	assert.Equal(t, peekElem{ch: Char{Rune: EOF}}, obj.last)
+	src.AssertExpectations(t)
+}
+
+func TestRewindScannerReadExhausted(t *testing.T) {
+	obj := &RewindScanner{
+		last: peekElem{ch: Char{Rune: EOF}},
+	}
+
+	result := obj.read()
+
+	assert.Equal(t, peekElem{ch: Char{Rune: EOF}}, result)
+}
+
+func TestRewindScannerTrimNoMarks(t *testing.T) {
+	chars := rewindChars('a', 'b', 'c')
+	obj := &RewindScanner{
+		buf: []peekElem{
+			{ch: chars[0]},
+			{ch: chars[1]},
+			{ch: chars[2]},
+		},
+		base:  3,
+		pos:   5,
+		marks: map[int]int{},
+	}
+
+	obj.trim()
+
+	assert.Equal(t, []peekElem{{ch: chars[2]}}, obj.buf)
+	assert.Equal(t, 1, cap(obj.buf))
+	assert.Equal(t, 5, obj.base)
+}
+
+func TestRewindScannerTrimMarks(t *testing.T) {
+	chars := rewindChars('a', 'b', 'c')
+	obj := &RewindScanner{
+		buf: []peekElem{
+			{ch: chars[0]},
+			{ch: chars[1]},
+			{ch: chars[2]},
+		},
+		base:  3,
+		pos:   6,
+		marks: map[int]int{4: 1, 5: 2},
+	}
+	orig := obj.buf
+
+	obj.trim()
+
+	assert.Equal(t, []peekElem{{ch: chars[1]}, {ch: chars[2]}}, obj.buf)
+	assert.Equal(t, peekElem{}, orig[0])
+	assert.Equal(t, 4, obj.base)
+}
+
+func TestRewindScannerTrimEmpty(t *testing.T) {
+	chars := rewindChars('a')
+	obj := &RewindScanner{
+		buf:   []peekElem{{ch: chars[0]}},
+		base:  3,
+		pos:   4,
+		marks: map[int]int{},
+	}
+
+	obj.trim()
+
+	assert.Nil(t, obj.buf)
+	assert.Equal(t, 4, obj.base)
+}
+
+func TestRewindScannerNextSource(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 'c'}, assert.AnError)
+	obj := &RewindScanner{
+		src:   src,
+		marks: map[int]int{0: 1},
+	}
+
+	result, err := obj.Next()
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, Char{Rune: 'c'}, result)
+	assert.Equal(t, []peekElem{{ch: Char{Rune: 'c'}, err: assert.AnError}}, obj.buf)
+	assert.Equal(t, 1, obj.pos)
+	assert.Equal(t, 0, obj.base)
+	src.AssertExpectations(t)
+}
+
+func TestRewindScannerNextRetained(t *testing.T) {
+	chars := rewindChars('a', 'b')
+	src := &mockScanner{}
+	obj := &RewindScanner{
+		src:   src,
+		buf:   []peekElem{{ch: chars[0]}, {ch: chars[1]}},
+		base:  2,
+		pos:   2,
+		marks: map[int]int{},
+	}
+
+	result, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, chars[0], result)
+	assert.Equal(t, []peekElem{{ch: chars[1]}}, obj.buf)
+	assert.Equal(t, 3, obj.pos)
+	assert.Equal(t, 3, obj.base)
+	src.AssertExpectations(t)
+}
+
+func TestRewindScannerNextPastEOF(t *testing.T) {
+	chars := rewindChars('a')
+	eof := Char{Rune: EOF, Loc: chars[0].Loc}
+	obj := &RewindScanner{
+		buf:   []peekElem{{ch: chars[0]}, {ch: eof, err: assert.AnError}},
+		base:  0,
+		pos:   2,
+		marks: map[int]int{0: 1},
+		last:  peekElem{ch: eof},
+	}
+
+	for i := 0; i < 3; i++ {
+		result, err := obj.Next()
+
+		assert.NoError(t, err)
+		assert.Equal(t, eof, result)
+	}
+	assert.Len(t, obj.buf, 2)
+	assert.Equal(t, 2, obj.pos)
+}
+
+func TestRewindScannerMark(t *testing.T) {
+	obj := &RewindScanner{
+		pos:   3,
+		marks: map[int]int{3: 1},
+	}
+
+	result := obj.Mark()
+
+	assert.Equal(t, Mark{pos: 3}, result)
+	assert.Equal(t, map[int]int{3: 2}, obj.marks)
+}
+
+func TestRewindScannerResetBase(t *testing.T) {
+	obj := &RewindScanner{
+		pos:   5,
+		marks: map[int]int{3: 1},
+	}
+
+	err := obj.Reset(Mark{pos: 3})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, obj.pos)
+}
+
+func TestRewindScannerResetReleased(t *testing.T) {
+	obj := &RewindScanner{
+		pos:   5,
+		marks: map[int]int{},
+	}
+
+	err := obj.Reset(Mark{pos: 3})
+
+	assert.Same(t, ErrBadMark, err)
+	assert.Equal(t, 5, obj.pos)
+}
+
+func TestRewindScannerReleaseShared(t *testing.T) {
+	chars := rewindChars('a', 'b')
+	obj := &RewindScanner{
+		buf:   []peekElem{{ch: chars[0]}, {ch: chars[1]}},
+		base:  3,
+		pos:   5,
+		marks: map[int]int{3: 2},
+	}
+
+	obj.Release(Mark{pos: 3})
+
+	assert.Equal(t, map[int]int{3: 1}, obj.marks)
+	assert.Len(t, obj.buf, 2)
+}
+
+func TestRewindScannerReleaseLast(t *testing.T) {
+	chars := rewindChars('a', 'b')
+	obj := &RewindScanner{
+		buf:   []peekElem{{ch: chars[0]}, {ch: chars[1]}},
+		base:  3,
+		pos:   5,
+		marks: map[int]int{3: 1},
+	}
+
+	obj.Release(Mark{pos: 3})
+
+	assert.Equal(t, map[int]int{}, obj.marks)
+	assert.Nil(t, obj.buf)
+	assert.Equal(t, 5, obj.base)
+}
+
+func TestRewindScannerSeekBase(t *testing.T) {
+	chars := rewindChars('a', 'b', 'c')
+	obj := &RewindScanner{
+		buf:   []peekElem{{ch: chars[0]}, {ch: chars[1]}, {ch: chars[2]}},
+		base:  3,
+		pos:   6,
+		marks: map[int]int{3: 1},
+	}
+
+	err := obj.Seek(chars[1].Loc)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, obj.pos)
+	assert.Len(t, obj.buf, 3)
+}
+
+func TestRewindScannerSeekMissing(t *testing.T) {
+	chars := rewindChars('a', 'b', 'c', 'd')
+	obj := &RewindScanner{
+		buf:   []peekElem{{ch: chars[0]}, {ch: chars[1]}, {ch: chars[2]}},
+		base:  3,
+		pos:   6,
+		marks: map[int]int{3: 1},
+	}
+
+	err := obj.Seek(chars[3].Loc)
+
+	assert.Same(t, ErrSeekLocation, err)
+	assert.Equal(t, 6, obj.pos)
+}
+
+func TestRewindScannerIntegration(t *testing.T) {
+	chars := rewindChars('a', 'b', 'c', 'd', EOF)
+	obj := NewRewindScanner(NewListScanner(chars, nil))
+	next := func() rune {
+		ch, _ := obj.Next()
+		return ch.Rune
+	}
+
+	assert.Equal(t, 'a', next())
+	m1 := obj.Mark()
+	assert.Equal(t, 'b', next())
+	m2 := obj.Mark()
+	assert.Equal(t, 'c', next())
+	assert.NoError(t, obj.Reset(m1))
+	assert.Equal(t, 'b', next())
+	assert.Equal(t, 'c', next())
+	assert.Equal(t, 'd', next())
+	assert.NoError(t, obj.Seek(chars[2].Loc))
+	assert.Equal(t, 'c', next())
+	obj.Release(m1)
+	assert.Same(t, ErrBadMark, obj.Reset(m1))
+	assert.Len(t, obj.buf, 2)
+	assert.NoError(t, obj.Reset(m2))
+	obj.Release(m2)
+	assert.Len(t, obj.buf, 2)
+	assert.Equal(t, 'c', next())
+	assert.Len(t, obj.buf, 1)
+	assert.Equal(t, 'd', next())
+	assert.Equal(t, EOF, next())
+	assert.Nil(t, obj.buf)
+	assert.Same(t, ErrSeekLocation, obj.Seek(chars[1].Loc))
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kinds of line endings tracked by the FileScanner when sanitizing.
+const (
+	eolNone = iota // No line ending seen
+	eolLF          // Bare newline
+	eolCRLF        // Carriage return and newline
+	eolCR          // Bare carriage return
+)
+
+// SanitizePolicy describes how a FileScanner sanitizes its input.  It
+// may be passed to the Sanitize option.
+type SanitizePolicy struct {
+	// Control is the action to take for control characters,
+	// including NUL, other than tab, form feed, and the line
+	// terminators recognized by the line ending style.
+	Control Action
+
+	// Replacement is the character to replace control characters
+	// with when Control is ActionReplace.  If it is 0, the
+	// Unicode replacement character, U+FFFD, is used.
+	Replacement rune
+
+	// LineEndings is the action to take for line endings that
+	// differ from the first line ending in the file; that is,
+	// when the file mixes newlines, carriage return and newline
+	// pairs, and bare carriage returns.  Only ActionReject and
+	// ActionWarn are meaningful; other actions allow mixed line
+	// endings.
+	LineEndings Action
+
+	// Warn is the handler for warnings.
+	Warn WarningHandler
+}
+
+// report is a helper that reports a character with an error, as
+// designated by the action.  It returns the error for the character,
+// if it is to be rejected.
+func (p *SanitizePolicy) report(action Action, loc Location, err error) error {
+	switch action {
+	case ActionReject:
+		return LocationError(loc, err)
+
+	case ActionWarn:
+		if p.Warn != nil {
+			p.Warn.Warn(LocationError(loc, err))
+		}
+	}
+
+	return nil
+}
+
+// isControl is a helper that checks to see if a character is a
+// control character subject to the sanitization policy.
+func (s *FileScanner) isControl(ch rune) bool {
+	return unicode.IsControl(ch) && ch != '\t' && ch != '\f' && !s.isTerminator(ch)
+}
+
+// sanitize is a helper for next that strips or replaces control
+// characters, as designated by the sanitization policy.  It returns
+// skipRune if the character should be stripped.
+func (s *FileScanner) sanitize(ch rune) rune {
+	if s.san == nil || !s.isControl(ch) {
+		return ch
+	}
+
+	switch s.san.Control {
+	case ActionStrip:
+		return skipRune
+
+	case ActionReplace:
+		if s.san.Replacement != 0 {
+			return s.san.Replacement
+		}
+		return utf8.RuneError
+	}
+
+	return ch
+}
+
+// lineEnding is a helper for char that tracks the kinds of line
+// endings in the file, reporting those that differ from the first.
+// It is passed the sequence of runes passed to the line ending style,
+// which is nil if the character did not begin a line ending, and the
+// location of the character.  It returns the error for the character,
+// if any.
+func (s *FileScanner) lineEnding(seq []rune, loc Location) error {
+	// Determine the kind of line ending
+	kind, kloc := eolNone, loc
+	switch {
+	case len(seq) > 1 && seq[0] == '\r':
+		kind = eolCR
+		if seq[1] == '\n' {
+			kind = eolCRLF
+		}
+	case len(seq) == 1 && seq[0] == '\r':
+		// Need to see the next character
+		s.crLoc = loc
+		return nil
+	case len(seq) > 0 && seq[0] == '\n':
+		kind = eolLF
+		if s.crLoc != nil {
+			kind = eolCRLF
+		}
+	case s.crLoc != nil:
+		kind, kloc = eolCR, s.crLoc
+	}
+	s.crLoc = nil
+
+	// Compare to the first line ending
+	switch {
+	case kind == eolNone:
+		return nil
+	case s.eol == eolNone:
+		s.eol = kind
+		return nil
+	case kind == s.eol:
+		return nil
+	}
+
+	return s.san.report(s.san.LineEndings, kloc, ErrMixedLineEndings)
+}
+
+// check is a helper for char that checks a character against the
+// sanitization policy.  It is passed the character, its location,
+// and the sequence of runes passed to the line ending style, which is
+// nil if the character did not begin a line ending.  It returns the
+// error for the character, if any.
+func (s *FileScanner) check(ch rune, loc Location, seq []rune) error {
+	if s.san == nil {
+		return nil
+	}
+
+	if err := s.lineEnding(seq, loc); err != nil {
+		return err
+	}
+
+	if s.isControl(ch) {
+		return s.san.report(s.san.Control, loc, ErrControlChar)
+	}
+
+	return nil
+}
@@ -0,0 +1,363 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizePolicyReportReject(t *testing.T) {
+	loc := &mockLocation{}
+	warn := &mockWarningHandler{}
+	obj := &SanitizePolicy{Warn: warn}
+
+	err := obj.report(ActionReject, loc, assert.AnError)
+
+	assert.Equal(t, &locationError{loc: loc, err: assert.AnError}, err)
+	warn.AssertExpectations(t)
+}
+
+func TestSanitizePolicyReportWarn(t *testing.T) {
+	loc := &mockLocation{}
+	warn := &mockWarningHandler{}
+	warn.On("Warn", &locationError{loc: loc, err: assert.AnError})
+	obj := &SanitizePolicy{Warn: warn}
+
+	err := obj.report(ActionWarn, loc, assert.AnError)
+
+	assert.NoError(t, err)
+	warn.AssertExpectations(t)
+}
+
+func TestSanitizePolicyReportWarnNoHandler(t *testing.T) {
+	loc := &mockLocation{}
+	obj := &SanitizePolicy{}
+
+	err := obj.report(ActionWarn, loc, assert.AnError)
+
+	assert.NoError(t, err)
+}
+
+func TestSanitizePolicyReportAllow(t *testing.T) {
+	loc := &mockLocation{}
+	warn := &mockWarningHandler{}
+	obj := &SanitizePolicy{Warn: warn}
+
+	err := obj.report(ActionAllow, loc, assert.AnError)
+
+	assert.NoError(t, err)
+	warn.AssertExpectations(t)
+}
+
+func TestFileScannerIsControl(t *testing.T) {
+	obj := &FileScanner{ls: UNIXLineStyle}
+
+	for _, r := range []rune{0, 0x1b, 0x7f, 0x85} {
+		assert.True(t, obj.isControl(r), "%U", r)
+	}
+	for _, r := range []rune{'\t', '\f', '\n', '\r', 'a', 0xa0, EOF} {
+		assert.False(t, obj.isControl(r), "%U", r)
+	}
+}
+
+func TestFileScannerIsControlTerminator(t *testing.T) {
+	obj := &FileScanner{ls: UnicodeLineStyle}
+
+	assert.False(t, obj.isControl(NextLine))
+}
+
+func TestFileScannerSanitizeNoPolicy(t *testing.T) {
+	obj := &FileScanner{ls: UNIXLineStyle}
+
+	assert.Equal(t, rune(0), obj.sanitize(0))
+}
+
+func TestFileScannerSanitizeNotControl(t *testing.T) {
+	obj := &FileScanner{
+		ls:  UNIXLineStyle,
+		san: &SanitizePolicy{Control: ActionStrip},
+	}
+
+	assert.Equal(t, 'a', obj.sanitize('a'))
+}
+
+func TestFileScannerSanitizeStrip(t *testing.T) {
+	obj := &FileScanner{
+		ls:  UNIXLineStyle,
+		san: &SanitizePolicy{Control: ActionStrip},
+	}
+
+	assert.Equal(t, skipRune, obj.sanitize(0))
+}
+
+func TestFileScannerSanitizeReplace(t *testing.T) {
+	obj := &FileScanner{
+		ls:  UNIXLineStyle,
+		san: &SanitizePolicy{Control: ActionReplace},
+	}
+
+	assert.Equal(t, utf8.RuneError, obj.sanitize(0))
+}
+
+func TestFileScannerSanitizeReplaceWith(t *testing.T) {
+	obj := &FileScanner{
+		ls:  UNIXLineStyle,
+		san: &SanitizePolicy{Control: ActionReplace, Replacement: '?'},
+	}
+
+	assert.Equal(t, '?', obj.sanitize(0))
+}
+
+func TestFileScannerSanitizeReject(t *testing.T) {
+	obj := &FileScanner{
+		ls:  UNIXLineStyle,
+		san: &SanitizePolicy{Control: ActionReject},
+	}
+
+	assert.Equal(t, rune(0), obj.sanitize(0))
+}
+
+func TestFileScannerLineEndingNone(t *testing.T) {
+	loc := &mockLocation{}
+	obj := &FileScanner{san: &SanitizePolicy{}}
+
+	err := obj.lineEnding(nil, loc)
+
+	assert.NoError(t, err)
+	assert.Equal(t, eolNone, obj.eol)
+}
+
+func TestFileScannerLineEndingFirst(t *testing.T) {
+	loc := &mockLocation{}
+	obj := &FileScanner{san: &SanitizePolicy{}}
+
+	err := obj.lineEnding([]rune{'\r', '\n'}, loc)
+
+	assert.NoError(t, err)
+	assert.Equal(t, eolCRLF, obj.eol)
+}
+
+func TestFileScannerLineEndingSame(t *testing.T) {
+	loc := &mockLocation{}
+	obj := &FileScanner{
+		san: &SanitizePolicy{},
+		eol: eolLF,
+	}
+
+	err := obj.lineEnding([]rune{'\n'}, loc)
+
+	assert.NoError(t, err)
+	assert.Equal(t, eolLF, obj.eol)
+}
+
+func TestFileScannerLineEndingMixed(t *testing.T) {
+	loc := &mockLocation{}
+	obj := &FileScanner{
+		san: &SanitizePolicy{},
+		eol: eolLF,
+	}
+
+	err := obj.lineEnding([]rune{'\r', 'a'}, loc)
+
+	assert.Equal(t, &locationError{loc: loc, err: ErrMixedLineEndings}, err)
+	assert.Equal(t, eolLF, obj.eol)
+}
+
+func TestFileScannerLineEndingPendingCR(t *testing.T) {
+	loc := &mockLocation{}
+	obj := &FileScanner{
+		san: &SanitizePolicy{},
+		eol: eolLF,
+	}
+
+	err := obj.lineEnding([]rune{'\r'}, loc)
+
+	assert.NoError(t, err)
+	assert.Same(t, loc, obj.crLoc)
+}
+
+func TestFileScannerLineEndingPendingCRLF(t *testing.T) {
+	crLoc := &mockLocation{}
+	loc := &mockLocation{}
+	obj := &FileScanner{
+		san:   &SanitizePolicy{},
+		eol:   eolLF,
+		crLoc: crLoc,
+	}
+
+	err := obj.lineEnding([]rune{'\n'}, loc)
+
+	assert.Equal(t, &locationError{loc: loc, err: ErrMixedLineEndings}, err)
+	assert.Nil(t, obj.crLoc)
+}
+
+func TestFileScannerLineEndingPendingCRResolved(t *testing.T) {
+	crLoc := &mockLocation{}
+	loc := &mockLocation{}
+	obj := &FileScanner{
+		san:   &SanitizePolicy{},
+		eol:   eolLF,
+		crLoc: crLoc,
+	}
+
+	err := obj.lineEnding(nil, loc)
+
+	assert.Equal(t, &locationError{loc: crLoc, err: ErrMixedLineEndings}, err)
+	assert.Nil(t, obj.crLoc)
+}
+
+func TestFileScannerCheckNoPolicy(t *testing.T) {
+	loc := &mockLocation{}
+	obj := &FileScanner{ls: UNIXLineStyle}
+
+	err := obj.check(0, loc, nil)
+
+	assert.NoError(t, err)
+}
+
+func TestFileScannerCheckControl(t *testing.T) {
+	loc := &mockLocation{}
+	obj := &FileScanner{
+		ls:  UNIXLineStyle,
+		san: &SanitizePolicy{},
+	}
+
+	err := obj.check(0, loc, nil)
+
+	assert.Equal(t, &locationError{loc: loc, err: ErrControlChar}, err)
+}
+
+func TestFileScannerCheckLineEnding(t *testing.T) {
+	loc := &mockLocation{}
+	obj := &FileScanner{
+		ls:  UNIXLineStyle,
+		san: &SanitizePolicy{},
+		eol: eolCRLF,
+	}
+
+	err := obj.check('\n', loc, []rune{'\n'})
+
+	assert.Equal(t, &locationError{loc: loc, err: ErrMixedLineEndings}, err)
+}
+
+func TestFileScannerCheckClean(t *testing.T) {
+	loc := &mockLocation{}
+	obj := &FileScanner{
+		ls:  UNIXLineStyle,
+		san: &SanitizePolicy{},
+	}
+
+	err := obj.check('a', loc, nil)
+
+	assert.NoError(t, err)
+}
+
+// sanitizeScan is a helper that scans text with a sanitization
+// policy, returning the text scanned and the errors encountered.
+func sanitizeScan(text string, options ...FileOption) (string, []error) {
+	s := NewFileScanner(bytes.NewBufferString(text), FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}, options...)
+
+	buf := &bytes.Buffer{}
+	errs := []error{}
+	for {
+		ch, err := s.Next()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if ch.Rune == EOF {
+			return buf.String(), errs
+		}
+		buf.WriteRune(ch.Rune)
+	}
+}
+
+func TestSanitizeControlReject(t *testing.T) {
+	text, errs := sanitizeScan("a\x00b", Sanitize(SanitizePolicy{}))
+
+	assert.Equal(t, "a\x00b", text)
+	assert.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs[0], ErrControlChar))
+	assert.Equal(t, FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 2, O: 1, R: 1},
+		E:    FilePos{L: 1, C: 3, O: 2, R: 2},
+	}, LocationOf(errs[0]))
+}
+
+func TestSanitizeControlReplace(t *testing.T) {
+	text, errs := sanitizeScan("a\x00b\x1b", Sanitize(SanitizePolicy{
+		Control: ActionReplace,
+	}))
+
+	assert.Equal(t, "a�b�", text)
+	assert.Empty(t, errs)
+}
+
+func TestSanitizeControlStrip(t *testing.T) {
+	s := NewFileScanner(bytes.NewBufferString("a\x00\x00b"), FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	}, Sanitize(SanitizePolicy{Control: ActionStrip}))
+
+	_, err := s.Next()
+	assert.NoError(t, err)
+	ch, err := s.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{
+		Rune: 'b',
+		Loc: FileLocation{
+			File: "file",
+			B:    FilePos{L: 1, C: 2, O: 1, R: 1},
+			E:    FilePos{L: 1, C: 3, O: 4, R: 4},
+		},
+	}, ch)
+}
+
+func TestSanitizeMixedLineEndingsWarn(t *testing.T) {
+	warnings := []error{}
+	text, errs := sanitizeScan("a\r\nb\nc\r\nd\re", LineEndings(DOSLineStyle), Sanitize(SanitizePolicy{
+		Control:     ActionAllow,
+		LineEndings: ActionWarn,
+		Warn: WarningHandlerFunc(func(err error) {
+			warnings = append(warnings, err)
+		}),
+	}))
+
+	assert.Equal(t, "a\nb\nc\nd e", text)
+	assert.Empty(t, errs)
+	assert.Len(t, warnings, 2)
+	assert.Equal(t, "file:2:2-3:1: Line ending differs from the first line ending", warnings[0].Error())
+	assert.Equal(t, "file:4:2: Line ending differs from the first line ending", warnings[1].Error())
+}
+
+func TestSanitizeMixedLineEndingsLocked(t *testing.T) {
+	text, errs := sanitizeScan("a\nb\r\nc\r", Sanitize(SanitizePolicy{}))
+
+	assert.Equal(t, "a\nb \nc ", text)
+	assert.Len(t, errs, 2)
+	assert.Equal(t, "file:2:3-3:1: Line ending differs from the first line ending", errs[0].Error())
+	assert.Equal(t, "file:3:2: Line ending differs from the first line ending", errs[1].Error())
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// CharClass identifies a class of characters that may be used to
+// disguise the meaning of source text.
+type CharClass int
+
+// Classes of characters recognized by SecurityScanner.
+const (
+	BidiControl CharClass = iota // Bidirectional control characters
+	ZeroWidth                    // Invisible, zero-width characters
+	MixedScript                  // Letters that mix confusable scripts
+)
+
+// Action is the action that a scanner takes when it encounters a
+// suspect character, as designated by a SecurityPolicy or a
+// SanitizePolicy.
+type Action int
+
+// Actions that a scanner may take.  The zero value rejects the
+// character.
+const (
+	ActionReject  Action = iota // Return the character with an error
+	ActionWarn                  // Report the character as a warning
+	ActionStrip                 // Remove the character
+	ActionAllow                 // Pass the character through
+	ActionReplace               // Replace the character
+)
+
+// WarningHandler is an interface for a warning handler.  A
+// SecurityScanner calls the Warn method of the warning handler with a
+// located error for each character it is configured to warn about.
+type WarningHandler interface {
+	// Warn reports the warning.
+	Warn(err error)
+}
+
+// WarningHandlerFunc is an implementation of WarningHandler that
+// wraps a function.
+type WarningHandlerFunc func(err error)
+
+// Warn reports the warning.
+func (f WarningHandlerFunc) Warn(err error) {
+	f(err)
+}
+
+// SecurityPolicy describes the action that a SecurityScanner takes
+// for each class of character.
+type SecurityPolicy struct {
+	Bidi        Action         // Action for bidirectional controls
+	ZeroWidth   Action         // Action for zero-width characters
+	MixedScript Action         // Action for mixed-script letters
+	Warn        WarningHandler // Handler for warnings
+}
+
+// action returns the action to take for the designated class of
+// character.
+func (p SecurityPolicy) action(class CharClass) Action {
+	switch class {
+	case BidiControl:
+		return p.Bidi
+	case ZeroWidth:
+		return p.ZeroWidth
+	default:
+		return p.MixedScript
+	}
+}
+
+// classErrors maps each class of character to the error reported for
+// it.
+var classErrors = map[CharClass]error{
+	BidiControl: ErrBidiControl,
+	ZeroWidth:   ErrZeroWidth,
+	MixedScript: ErrMixedScript,
+}
+
+// bidiTable is a table of the bidirectional control characters, which
+// can be used to reorder the display of source text.
+var bidiTable = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x061c, Hi: 0x061c, Stride: 1},
+		{Lo: 0x200e, Hi: 0x200f, Stride: 1},
+		{Lo: 0x202a, Hi: 0x202e, Stride: 1},
+		{Lo: 0x2066, Hi: 0x2069, Stride: 1},
+	},
+}
+
+// zeroWidthTable is a table of the characters that are not displayed,
+// and which can be used to make distinct identifiers appear the same.
+var zeroWidthTable = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x00ad, Hi: 0x00ad, Stride: 1},
+		{Lo: 0x180e, Hi: 0x180e, Stride: 1},
+		{Lo: 0x200b, Hi: 0x200d, Stride: 1},
+		{Lo: 0x2060, Hi: 0x2060, Stride: 1},
+		{Lo: 0xfeff, Hi: 0xfeff, Stride: 1},
+	},
+	LatinOffset: 1,
+}
+
+// confusableScripts is a list of the scripts whose letters are
+// commonly confused with one another.  A word containing letters from
+// more than one of these scripts is reported as mixed-script.
+var confusableScripts = []*unicode.RangeTable{
+	unicode.Latin,
+	unicode.Greek,
+	unicode.Cyrillic,
+	unicode.Armenian,
+	unicode.Cherokee,
+}
+
+// SecurityScanner is a scanner that wraps another scanner and detects
+// characters that may be used to disguise the meaning of source text,
+// such as in "Trojan Source" attacks.  It detects bidirectional
+// control characters, zero-width characters, and letters within a
+// word that are drawn from different scripts which contain
+// confusable letters, such as Latin and Cyrillic.  Each class of
+// character may be rejected, reported as a warning, stripped,
+// replaced with the Unicode replacement character, or allowed, as
+// designated by a SecurityPolicy.  Mixed-script words are
+// reported at each letter where the script changes.
+type SecurityScanner struct {
+	src    Scanner             // The source scanner
+	policy SecurityPolicy      // The policy to apply
+	script *unicode.RangeTable // Script of the letters in the word
+}
+
+// NewSecurityScanner wraps another scanner in a SecurityScanner,
+// which applies the designated policy.
+func NewSecurityScanner(src Scanner, policy SecurityPolicy) *SecurityScanner {
+	return &SecurityScanner{
+		src:    src,
+		policy: policy,
+	}
+}
+
+// classify is a helper for Next that determines the class of a
+// character.  It returns false if the character is not in any of the
+// classes.  It also tracks the script of the letters in the current
+// word, reporting a mixed-script letter when the script changes.
+func (ss *SecurityScanner) classify(r rune) (CharClass, bool) {
+	switch {
+	case unicode.Is(bidiTable, r):
+		return BidiControl, true
+
+	case unicode.Is(zeroWidthTable, r):
+		return ZeroWidth, true
+
+	case !unicode.In(r, unicode.L, unicode.M, unicode.N) && r != '_':
+		// End of the word
+		ss.script = nil
+		return 0, false
+	}
+
+	// Check the script of the letter
+	for _, script := range confusableScripts {
+		if !unicode.Is(script, r) {
+			continue
+		}
+
+		if ss.script == nil {
+			ss.script = script
+		} else if ss.script != script {
+			ss.script = script
+			return MixedScript, true
+		}
+		break
+	}
+
+	return 0, false
+}
+
+// Next returns the next character from the stream as a Char, which
+// will include the character's location.  If an error was
+// encountered, that will also be returned.
+func (ss *SecurityScanner) Next() (Char, error) {
+	for {
+		ch, err := ss.src.Next()
+		if err != nil || ch.Rune == EOF {
+			return ch, err
+		}
+
+		class, ok := ss.classify(ch.Rune)
+		if !ok {
+			return ch, nil
+		}
+
+		switch ss.policy.action(class) {
+		case ActionReject:
+			return ch, LocationError(ch.Loc, classErrors[class])
+
+		case ActionWarn:
+			if ss.policy.Warn != nil {
+				ss.policy.Warn.Warn(LocationError(ch.Loc, classErrors[class]))
+			}
+			return ch, nil
+
+		case ActionStrip:
+			continue
+
+		case ActionReplace:
+			ch.Rune = utf8.RuneError
+			return ch, nil
+
+		default:
+			return ch, nil
+		}
+	}
+}
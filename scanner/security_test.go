@@ -0,0 +1,346 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockWarningHandler struct {
+	mock.Mock
+}
+
+func (m *mockWarningHandler) Warn(err error) {
+	m.MethodCalled("Warn", err)
+}
+
+func TestWarningHandlerFuncImplementsWarningHandler(t *testing.T) {
+	assert.Implements(t, (*WarningHandler)(nil), WarningHandlerFunc(nil))
+}
+
+func TestWarningHandlerFuncWarn(t *testing.T) {
+	var warned error
+	obj := WarningHandlerFunc(func(err error) {
+		warned = err
+	})
+
+	obj.Warn(assert.AnError)
+
+	assert.Same(t, assert.AnError, warned)
+}
+
+func TestSecurityPolicyAction(t *testing.T) {
+	obj := SecurityPolicy{
+		Bidi:        ActionWarn,
+		ZeroWidth:   ActionStrip,
+		MixedScript: ActionAllow,
+	}
+
+	assert.Equal(t, ActionWarn, obj.action(BidiControl))
+	assert.Equal(t, ActionStrip, obj.action(ZeroWidth))
+	assert.Equal(t, ActionAllow, obj.action(MixedScript))
+}
+
+func TestSecurityPolicyDefault(t *testing.T) {
+	obj := SecurityPolicy{}
+
+	assert.Equal(t, ActionReject, obj.action(BidiControl))
+	assert.Equal(t, ActionReject, obj.action(ZeroWidth))
+	assert.Equal(t, ActionReject, obj.action(MixedScript))
+}
+
+func TestSecurityScannerImplementsScanner(t *testing.T) {
+	assert.Implements(t, (*Scanner)(nil), &SecurityScanner{})
+}
+
+func TestNewSecurityScanner(t *testing.T) {
+	src := &mockScanner{}
+	policy := SecurityPolicy{Bidi: ActionWarn}
+
+	result := NewSecurityScanner(src, policy)
+
+	assert.Equal(t, &SecurityScanner{
+		src:    src,
+		policy: policy,
+	}, result)
+}
+
+func TestSecurityScannerClassifyBidi(t *testing.T) {
+	obj := &SecurityScanner{script: unicode.Latin}
+
+	for _, r := range []rune{0x061c, 0x200e, 0x200f, 0x202a, 0x202e, 0x2066, 0x2069} {
+		class, ok := obj.classify(r)
+
+		assert.True(t, ok, "%U", r)
+		assert.Equal(t, BidiControl, class, "%U", r)
+	}
+	assert.Same(t, unicode.Latin, obj.script)
+}
+
+func TestSecurityScannerClassifyZeroWidth(t *testing.T) {
+	obj := &SecurityScanner{script: unicode.Latin}
+
+	for _, r := range []rune{0x00ad, 0x180e, 0x200b, 0x200c, 0x200d, 0x2060, 0xfeff} {
+		class, ok := obj.classify(r)
+
+		assert.True(t, ok, "%U", r)
+		assert.Equal(t, ZeroWidth, class, "%U", r)
+	}
+	assert.Same(t, unicode.Latin, obj.script)
+}
+
+func TestSecurityScannerClassifyWordEnd(t *testing.T) {
+	obj := &SecurityScanner{script: unicode.Latin}
+
+	_, ok := obj.classify(' ')
+
+	assert.False(t, ok)
+	assert.Nil(t, obj.script)
+}
+
+func TestSecurityScannerClassifyWordStart(t *testing.T) {
+	obj := &SecurityScanner{}
+
+	_, ok := obj.classify('а')
+
+	assert.False(t, ok)
+	assert.Same(t, unicode.Cyrillic, obj.script)
+}
+
+func TestSecurityScannerClassifySameScript(t *testing.T) {
+	obj := &SecurityScanner{script: unicode.Latin}
+
+	_, ok := obj.classify('a')
+
+	assert.False(t, ok)
+	assert.Same(t, unicode.Latin, obj.script)
+}
+
+func TestSecurityScannerClassifyOtherScript(t *testing.T) {
+	obj := &SecurityScanner{script: unicode.Latin}
+
+	for _, r := range []rune{'_', '1', '́', '中'} {
+		_, ok := obj.classify(r)
+
+		assert.False(t, ok, "%U", r)
+		assert.Same(t, unicode.Latin, obj.script)
+	}
+}
+
+func TestSecurityScannerClassifyMixed(t *testing.T) {
+	obj := &SecurityScanner{script: unicode.Latin}
+
+	class, ok := obj.classify('а')
+
+	assert.True(t, ok)
+	assert.Equal(t, MixedScript, class)
+	assert.Same(t, unicode.Cyrillic, obj.script)
+}
+
+func TestSecurityScannerNextBase(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 'a'}, nil)
+	obj := &SecurityScanner{src: src}
+
+	ch, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: 'a'}, ch)
+	src.AssertExpectations(t)
+}
+
+func TestSecurityScannerNextError(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 0x202e}, assert.AnError)
+	obj := &SecurityScanner{src: src}
+
+	ch, err := obj.Next()
+
+	assert.Same(t, assert.AnError, err)
+	assert.Equal(t, Char{Rune: 0x202e}, ch)
+	src.AssertExpectations(t)
+}
+
+func TestSecurityScannerNextEOF(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: EOF}, nil)
+	obj := &SecurityScanner{
+		src:    src,
+		script: unicode.Latin,
+	}
+
+	ch, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: EOF}, ch)
+	src.AssertExpectations(t)
+}
+
+func TestSecurityScannerNextReject(t *testing.T) {
+	loc := &mockLocation{}
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 0x202e, Loc: loc}, nil)
+	obj := &SecurityScanner{src: src}
+
+	ch, err := obj.Next()
+
+	assert.True(t, errors.Is(err, ErrBidiControl))
+	assert.Same(t, loc, LocationOf(err))
+	assert.Equal(t, Char{Rune: 0x202e, Loc: loc}, ch)
+	src.AssertExpectations(t)
+}
+
+func TestSecurityScannerNextWarn(t *testing.T) {
+	loc := &mockLocation{}
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 0x200b, Loc: loc}, nil)
+	warn := &mockWarningHandler{}
+	warn.On("Warn", &locationError{loc: loc, err: ErrZeroWidth})
+	obj := &SecurityScanner{
+		src: src,
+		policy: SecurityPolicy{
+			ZeroWidth: ActionWarn,
+			Warn:      warn,
+		},
+	}
+
+	ch, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: 0x200b, Loc: loc}, ch)
+	src.AssertExpectations(t)
+	warn.AssertExpectations(t)
+}
+
+func TestSecurityScannerNextWarnNoHandler(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 0x200b}, nil)
+	obj := &SecurityScanner{
+		src: src,
+		policy: SecurityPolicy{
+			ZeroWidth: ActionWarn,
+		},
+	}
+
+	ch, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: 0x200b}, ch)
+	src.AssertExpectations(t)
+}
+
+func TestSecurityScannerNextStrip(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 0x200b}, nil).Once()
+	src.On("Next").Return(Char{Rune: 'a'}, nil).Once()
+	obj := &SecurityScanner{
+		src: src,
+		policy: SecurityPolicy{
+			ZeroWidth: ActionStrip,
+		},
+	}
+
+	ch, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: 'a'}, ch)
+	src.AssertExpectations(t)
+}
+
+func TestSecurityScannerNextAllow(t *testing.T) {
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 0x200b}, nil)
+	obj := &SecurityScanner{
+		src: src,
+		policy: SecurityPolicy{
+			ZeroWidth: ActionAllow,
+		},
+	}
+
+	ch, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: 0x200b}, ch)
+	src.AssertExpectations(t)
+}
+
+func TestSecurityScannerNextReplace(t *testing.T) {
+	loc := &mockLocation{}
+	src := &mockScanner{}
+	src.On("Next").Return(Char{Rune: 0x200b, Loc: loc}, nil)
+	obj := &SecurityScanner{
+		src: src,
+		policy: SecurityPolicy{
+			ZeroWidth: ActionReplace,
+		},
+	}
+
+	ch, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{Rune: utf8.RuneError, Loc: loc}, ch)
+	src.AssertExpectations(t)
+}
+
+func TestSecurityScannerIntegration(t *testing.T) {
+	warnings := []error{}
+	src := NewFileScanner(bytes.NewBufferString("раypal = \"‮\"; x​y"), FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 1},
+	})
+	obj := NewSecurityScanner(src, SecurityPolicy{
+		Bidi:        ActionReject,
+		ZeroWidth:   ActionStrip,
+		MixedScript: ActionWarn,
+		Warn: WarningHandlerFunc(func(err error) {
+			warnings = append(warnings, err)
+		}),
+	})
+
+	text := []rune{}
+	errs := []error{}
+	for {
+		ch, err := obj.Next()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if ch.Rune == EOF {
+			break
+		}
+		text = append(text, ch.Rune)
+	}
+
+	assert.Equal(t, "раypal = \"‮\"; xy", string(text))
+	assert.Equal(t, []error{
+		&locationError{
+			loc: FileLocation{File: "file", B: FilePos{L: 1, C: 3, O: 4, R: 2}, E: FilePos{L: 1, C: 4, O: 5, R: 3}},
+			err: ErrMixedScript,
+		},
+	}, warnings)
+	assert.Equal(t, []error{
+		&locationError{
+			loc: FileLocation{File: "file", B: FilePos{L: 1, C: 11, O: 12, R: 10}, E: FilePos{L: 1, C: 12, O: 15, R: 11}},
+			err: ErrBidiControl,
+		},
+	}, errs)
+}
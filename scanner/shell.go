@@ -0,0 +1,237 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import "strings"
+
+// shellEscapes contains the characters that may be escaped with a
+// backslash within double quotes.
+const shellEscapes = "$`\"\\\n"
+
+// ShellArg describes an argument split from a command line by
+// SplitShell.
+type ShellArg struct {
+	Text  string      // The text of the argument
+	Loc   ArgLocation // The location of the argument, including quotes
+	Chars []Char      // The characters of the argument
+}
+
+// shellSplitter is a helper for SplitShell that contains the state of
+// the splitting.
+type shellSplitter struct {
+	runes []rune     // The runes of the command line
+	args  []ShellArg // The arguments split so far
+	cur   *ShellArg  // The argument being assembled
+}
+
+// start starts a new argument at the designated column, if one is not
+// already being assembled.
+func (ss *shellSplitter) start(col int) {
+	if ss.cur == nil {
+		ss.cur = &ShellArg{
+			Loc: ArgLocation{
+				B: ArgPos{I: len(ss.args) + 1, C: col},
+			},
+			Chars: []Char{},
+		}
+	}
+}
+
+// finish finishes the argument being assembled, if any, at the
+// designated column.
+func (ss *shellSplitter) finish(col int) {
+	if ss.cur != nil {
+		ss.cur.Loc.E = ArgPos{I: ss.cur.Loc.B.I, C: col}
+		buf := &strings.Builder{}
+		for _, ch := range ss.cur.Chars {
+			buf.WriteRune(ch.Rune)
+		}
+		ss.cur.Text = buf.String()
+
+		ss.args = append(ss.args, *ss.cur)
+		ss.cur = nil
+	}
+}
+
+// emit adds a character to the argument being assembled.  The
+// character was produced from the runes of the command line in the
+// designated range of columns.
+func (ss *shellSplitter) emit(r rune, b, e int) {
+	i := ss.cur.Loc.B.I
+	ss.cur.Chars = append(ss.cur.Chars, Char{
+		Rune: r,
+		Loc: ArgLocation{
+			B: ArgPos{I: i, C: b},
+			E: ArgPos{I: i, C: e},
+		},
+	})
+}
+
+// errorAt constructs a located error for the designated column.
+func (ss *shellSplitter) errorAt(col int, err error) error {
+	i := len(ss.args) + 1
+	return LocationError(ArgLocation{
+		B: ArgPos{I: i, C: col},
+		E: ArgPos{I: i, C: col + 1},
+	}, err)
+}
+
+// single handles a single-quoted string beginning at the designated
+// index.  It returns the index following the closing quote.
+func (ss *shellSplitter) single(i int) (int, error) {
+	ss.start(i + 1)
+	for j := i + 1; j < len(ss.runes); j++ {
+		if ss.runes[j] == '\'' {
+			return j + 1, nil
+		}
+		ss.emit(ss.runes[j], j+1, j+2)
+	}
+
+	return 0, ss.errorAt(i+1, ErrUnterminatedQuote)
+}
+
+// double handles a double-quoted string beginning at the designated
+// index.  It returns the index following the closing quote.
+func (ss *shellSplitter) double(i int) (int, error) {
+	ss.start(i + 1)
+	for j := i + 1; j < len(ss.runes); j++ {
+		switch r := ss.runes[j]; {
+		case r == '"':
+			return j + 1, nil
+
+		case r == '\\' && j+1 < len(ss.runes) && strings.ContainsRune(shellEscapes, ss.runes[j+1]):
+			// Escaped newlines are removed
+			if ss.runes[j+1] != '\n' {
+				ss.emit(ss.runes[j+1], j+1, j+3)
+			}
+			j++
+
+		default:
+			ss.emit(r, j+1, j+2)
+		}
+	}
+
+	return 0, ss.errorAt(i+1, ErrUnterminatedQuote)
+}
+
+// SplitShell splits a command line into arguments, following the
+// quoting rules of the POSIX shell.  Arguments are separated by
+// unquoted whitespace.  A backslash outside of quotes escapes the
+// following character; within single quotes, all characters are taken
+// literally; and within double quotes, a backslash escapes only "$",
+// "`", "\"", "\\", and newline.  An escaped newline is removed
+// entirely.  The locations of the arguments and their characters are
+// ArgLocation values, with the argument index (beginning at 1) and
+// the column of the command line (also beginning at 1).  A located
+// ErrUnterminatedQuote or ErrUnterminatedEscape error is returned if
+// a quoted string is not closed or the command line ends with a
+// backslash.
+func SplitShell(line string) ([]ShellArg, error) {
+	ss := &shellSplitter{
+		runes: []rune(line),
+		args:  []ShellArg{},
+	}
+
+	var err error
+	for i := 0; i < len(ss.runes); {
+		switch r := ss.runes[i]; r {
+		case ' ', '\t', '\n':
+			ss.finish(i + 1)
+			i++
+
+		case '\\':
+			if i+1 >= len(ss.runes) {
+				return nil, ss.errorAt(i+1, ErrUnterminatedEscape)
+			}
+
+			// Escaped newlines are removed
+			if ss.runes[i+1] != '\n' {
+				ss.start(i + 1)
+				ss.emit(ss.runes[i+1], i+1, i+3)
+			}
+			i += 2
+
+		case '\'':
+			if i, err = ss.single(i); err != nil {
+				return nil, err
+			}
+
+		case '"':
+			if i, err = ss.double(i); err != nil {
+				return nil, err
+			}
+
+		default:
+			ss.start(i + 1)
+			ss.emit(r, i+1, i+2)
+			i++
+		}
+	}
+	ss.finish(len(ss.runes) + 1)
+
+	return ss.args, nil
+}
+
+// NewShellScanner constructs and returns a Scanner implementation
+// that returns characters drawn from a command line, split into
+// arguments by SplitShell.  As for NewArgumentScanner, the arguments
+// are logically joined by spaces, and a different joiner may be
+// selected with the ArgJoiner option; the characters of the joiner
+// have locations with an argument index of 0.  Other options are
+// ignored.  The characters of the arguments have locations within
+// the command line, as described for SplitShell.
+func NewShellScanner(line string, options ...ArgOption) (Scanner, error) {
+	args, err := SplitShell(line)
+	if err != nil {
+		return nil, err
+	}
+
+	// Process the options
+	opts := &argOptions{
+		joiner: " ",
+	}
+	for _, opt := range options {
+		opt.argApply(opts)
+	}
+
+	// Assemble the characters
+	chars := []Char{}
+	end := ArgPos{I: 0, C: 1}
+	for i, arg := range args {
+		if i != 0 {
+			for j, r := range []rune(opts.joiner) {
+				chars = append(chars, Char{
+					Rune: r,
+					Loc: ArgLocation{
+						B: ArgPos{I: 0, C: j + 1},
+						E: ArgPos{I: 0, C: j + 2},
+					},
+				})
+			}
+		}
+
+		chars = append(chars, arg.Chars...)
+		end = arg.Loc.E
+	}
+	chars = append(chars, Char{
+		Rune: EOF,
+		Loc: ArgLocation{
+			B: end,
+			E: end,
+		},
+	})
+
+	return NewListScanner(chars, nil), nil
+}
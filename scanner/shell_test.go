@@ -0,0 +1,285 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shellTexts is a helper that extracts the text of the arguments
+// returned by SplitShell.
+func shellTexts(args []ShellArg) []string {
+	texts := []string{}
+	for _, arg := range args {
+		texts = append(texts, arg.Text)
+	}
+
+	return texts
+}
+
+func TestShellSplitterStart(t *testing.T) {
+	obj := &shellSplitter{
+		args: []ShellArg{{}, {}},
+	}
+
+	obj.start(5)
+
+	assert.Equal(t, &ShellArg{
+		Loc:   ArgLocation{B: ArgPos{I: 3, C: 5}},
+		Chars: []Char{},
+	}, obj.cur)
+}
+
+func TestShellSplitterStartStarted(t *testing.T) {
+	cur := &ShellArg{}
+	obj := &shellSplitter{
+		cur: cur,
+	}
+
+	obj.start(5)
+
+	assert.Same(t, cur, obj.cur)
+}
+
+func TestShellSplitterFinish(t *testing.T) {
+	obj := &shellSplitter{
+		args: []ShellArg{},
+		cur: &ShellArg{
+			Loc: ArgLocation{B: ArgPos{I: 1, C: 2}},
+			Chars: []Char{
+				{Rune: 'a'},
+				{Rune: 'b'},
+			},
+		},
+	}
+
+	obj.finish(5)
+
+	assert.Nil(t, obj.cur)
+	assert.Equal(t, []ShellArg{
+		{
+			Text: "ab",
+			Loc: ArgLocation{
+				B: ArgPos{I: 1, C: 2},
+				E: ArgPos{I: 1, C: 5},
+			},
+			Chars: []Char{
+				{Rune: 'a'},
+				{Rune: 'b'},
+			},
+		},
+	}, obj.args)
+}
+
+func TestShellSplitterFinishUnstarted(t *testing.T) {
+	obj := &shellSplitter{
+		args: []ShellArg{},
+	}
+
+	obj.finish(5)
+
+	assert.Equal(t, []ShellArg{}, obj.args)
+}
+
+func TestShellSplitterEmit(t *testing.T) {
+	obj := &shellSplitter{
+		cur: &ShellArg{
+			Loc:   ArgLocation{B: ArgPos{I: 2, C: 2}},
+			Chars: []Char{},
+		},
+	}
+
+	obj.emit('a', 3, 5)
+
+	assert.Equal(t, []Char{
+		{
+			Rune: 'a',
+			Loc: ArgLocation{
+				B: ArgPos{I: 2, C: 3},
+				E: ArgPos{I: 2, C: 5},
+			},
+		},
+	}, obj.cur.Chars)
+}
+
+func TestShellSplitterErrorAt(t *testing.T) {
+	obj := &shellSplitter{
+		args: []ShellArg{{}},
+	}
+
+	err := obj.errorAt(5, assert.AnError)
+
+	assert.True(t, errors.Is(err, assert.AnError))
+	assert.Equal(t, ArgLocation{
+		B: ArgPos{I: 2, C: 5},
+		E: ArgPos{I: 2, C: 6},
+	}, LocationOf(err))
+}
+
+func TestSplitShellBase(t *testing.T) {
+	result, err := SplitShell("  cmd  -a\tfile\n")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cmd", "-a", "file"}, shellTexts(result))
+	assert.Equal(t, ArgLocation{
+		B: ArgPos{I: 1, C: 3},
+		E: ArgPos{I: 1, C: 6},
+	}, result[0].Loc)
+	assert.Equal(t, ArgLocation{
+		B: ArgPos{I: 2, C: 8},
+		E: ArgPos{I: 2, C: 10},
+	}, result[1].Loc)
+	assert.Equal(t, ArgLocation{
+		B: ArgPos{I: 3, C: 11},
+		E: ArgPos{I: 3, C: 15},
+	}, result[2].Loc)
+}
+
+func TestSplitShellEmpty(t *testing.T) {
+	result, err := SplitShell(" \t ")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []ShellArg{}, result)
+}
+
+func TestSplitShellQuotes(t *testing.T) {
+	result, err := SplitShell(`a'b c'"d \"e\" \x" '' "$\\"`)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`ab cd "e" \x`, "", `$\`}, shellTexts(result))
+	assert.Equal(t, ArgLocation{
+		B: ArgPos{I: 1, C: 1},
+		E: ArgPos{I: 1, C: 19},
+	}, result[0].Loc)
+	assert.Equal(t, ArgLocation{
+		B: ArgPos{I: 2, C: 20},
+		E: ArgPos{I: 2, C: 22},
+	}, result[1].Loc)
+}
+
+func TestSplitShellEscapes(t *testing.T) {
+	result, err := SplitShell("a\\ b \\\n c\\\nd \\'")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a b", "cd", "'"}, shellTexts(result))
+}
+
+func TestSplitShellCharLocations(t *testing.T) {
+	result, err := SplitShell(`x "a\"b" \c`)
+
+	require.NoError(t, err)
+	assert.Equal(t, []Char{
+		{Rune: 'a', Loc: ArgLocation{B: ArgPos{I: 2, C: 4}, E: ArgPos{I: 2, C: 5}}},
+		{Rune: '"', Loc: ArgLocation{B: ArgPos{I: 2, C: 5}, E: ArgPos{I: 2, C: 7}}},
+		{Rune: 'b', Loc: ArgLocation{B: ArgPos{I: 2, C: 7}, E: ArgPos{I: 2, C: 8}}},
+	}, result[1].Chars)
+	assert.Equal(t, []Char{
+		{Rune: 'c', Loc: ArgLocation{B: ArgPos{I: 3, C: 10}, E: ArgPos{I: 3, C: 12}}},
+	}, result[2].Chars)
+}
+
+func TestSplitShellUnterminatedSingle(t *testing.T) {
+	result, err := SplitShell(`a b'cd`)
+
+	assert.True(t, errors.Is(err, ErrUnterminatedQuote))
+	assert.Equal(t, ArgLocation{
+		B: ArgPos{I: 2, C: 4},
+		E: ArgPos{I: 2, C: 5},
+	}, LocationOf(err))
+	assert.Nil(t, result)
+}
+
+func TestSplitShellUnterminatedDouble(t *testing.T) {
+	result, err := SplitShell(`"a\"`)
+
+	assert.True(t, errors.Is(err, ErrUnterminatedQuote))
+	assert.Equal(t, ArgLocation{
+		B: ArgPos{I: 1, C: 1},
+		E: ArgPos{I: 1, C: 2},
+	}, LocationOf(err))
+	assert.Nil(t, result)
+}
+
+func TestSplitShellUnterminatedEscape(t *testing.T) {
+	result, err := SplitShell(`a \`)
+
+	assert.True(t, errors.Is(err, ErrUnterminatedEscape))
+	assert.Equal(t, ArgLocation{
+		B: ArgPos{I: 2, C: 3},
+		E: ArgPos{I: 2, C: 4},
+	}, LocationOf(err))
+	assert.Nil(t, result)
+}
+
+func TestNewShellScannerBase(t *testing.T) {
+	obj, err := NewShellScanner(`a "b c"`)
+	require.NoError(t, err)
+
+	result := []Char{}
+	for {
+		ch, err := obj.Next()
+		require.NoError(t, err)
+		result = append(result, ch)
+		if ch.Rune == EOF {
+			break
+		}
+	}
+
+	assert.Equal(t, []Char{
+		{Rune: 'a', Loc: ArgLocation{B: ArgPos{I: 1, C: 1}, E: ArgPos{I: 1, C: 2}}},
+		{Rune: ' ', Loc: ArgLocation{B: ArgPos{I: 0, C: 1}, E: ArgPos{I: 0, C: 2}}},
+		{Rune: 'b', Loc: ArgLocation{B: ArgPos{I: 2, C: 4}, E: ArgPos{I: 2, C: 5}}},
+		{Rune: ' ', Loc: ArgLocation{B: ArgPos{I: 2, C: 5}, E: ArgPos{I: 2, C: 6}}},
+		{Rune: 'c', Loc: ArgLocation{B: ArgPos{I: 2, C: 6}, E: ArgPos{I: 2, C: 7}}},
+		{Rune: EOF, Loc: ArgLocation{B: ArgPos{I: 2, C: 8}, E: ArgPos{I: 2, C: 8}}},
+	}, result)
+}
+
+func TestNewShellScannerJoiner(t *testing.T) {
+	obj, err := NewShellScanner(`a b`, ArgJoiner("||"))
+	require.NoError(t, err)
+
+	result, err := scanAll(obj)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "a||b", result)
+}
+
+func TestNewShellScannerEmpty(t *testing.T) {
+	obj, err := NewShellScanner("")
+	require.NoError(t, err)
+
+	ch, err := obj.Next()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Char{
+		Rune: EOF,
+		Loc: ArgLocation{
+			B: ArgPos{I: 0, C: 1},
+			E: ArgPos{I: 0, C: 1},
+		},
+	}, ch)
+}
+
+func TestNewShellScannerError(t *testing.T) {
+	obj, err := NewShellScanner(`'a`)
+
+	assert.True(t, errors.Is(err, ErrUnterminatedQuote))
+	assert.Nil(t, obj)
+}
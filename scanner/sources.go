@@ -0,0 +1,276 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Source is the retained text of a single source file.  Sources are
+// created by a SourceRegistry, either explicitly through the Add
+// method or implicitly by a FileScanner constructed with the Retain
+// option.  The text retained is the text returned by the scanner,
+// which means that line endings have already been converted into
+// single newlines.
+type Source struct {
+	File    string     // The name of the file
+	TabStop int        // The tab stop used when scanning the file
+	Unit    ColumnUnit // The column unit used when scanning the file
+
+	mu    sync.Mutex      // Protects the text and lines
+	text  strings.Builder // The text of the source
+	lines []int           // Offsets of the beginning of each line
+}
+
+// newSource constructs a new, empty Source.
+func newSource(file string, tabstop int) *Source {
+	return &Source{
+		File:    file,
+		TabStop: tabstop,
+		lines:   []int{0},
+	}
+}
+
+// append appends a character to the source.
+func (s *Source) append(ch rune) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.text.WriteRune(ch)
+	if ch == '\n' {
+		s.lines = append(s.lines, s.text.Len())
+	}
+}
+
+// Text returns the text retained so far.
+func (s *Source) Text() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.text.String()
+}
+
+// Lines returns the number of lines retained so far.  A final line
+// that has been started but not yet terminated by a newline is
+// included in the count.
+func (s *Source) Lines() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lines[len(s.lines)-1] == s.text.Len() {
+		return len(s.lines) - 1
+	}
+	return len(s.lines)
+}
+
+// Line returns the text of the designated line, which is 1-indexed
+// to match FilePos.  The line's trailing newline is not included.  If
+// the line has not been retained, the boolean return value will be
+// false.
+func (s *Source) Line(n int) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n < 1 || n > len(s.lines) {
+		return "", false
+	}
+
+	text := s.text.String()
+	begin := s.lines[n-1]
+	if n < len(s.lines) {
+		return text[begin : s.lines[n]-1], true
+	} else if begin < len(text) {
+		return text[begin:], true
+	}
+
+	return "", false
+}
+
+// layout lays out a line for display.  It returns the line with tabs
+// expanded and other control characters removed, along with a list
+// mapping each column of the line, as computed by FileLocation, to the
+// display cell at which it begins.  The final element of the list is
+// the display width of the line.
+func (s *Source) layout(line string) (string, []int) {
+	buf := &bytes.Buffer{}
+	cells := []int{}
+	disp := 0
+	var loc OffsetLocation = FileLocation{
+		B: FilePos{L: 1, C: 1},
+		E: FilePos{L: 1, C: 1},
+	}
+	for _, ch := range line {
+		ext := Extent{Bytes: utf8.RuneLen(ch), Runes: 1}
+		ext.Cols = s.Unit.Columns(ch, ext)
+		fl := loc.IncrExtent(ch, s.TabStop, ext).(FileLocation)
+		loc = fl
+
+		// Map the columns to the display cells
+		for c := fl.B.C; c < fl.E.C; c++ {
+			if ch == '\t' {
+				cells = append(cells, disp+c-fl.B.C)
+			} else {
+				cells = append(cells, disp)
+			}
+		}
+
+		// Add the character to the display
+		switch {
+		case ch == '\t':
+			buf.WriteString(strings.Repeat(" ", fl.E.C-fl.B.C))
+			disp += fl.E.C - fl.B.C
+
+		case !unicode.IsControl(ch):
+			buf.WriteRune(ch)
+			disp += RuneWidth(ch)
+		}
+	}
+
+	return buf.String(), append(cells, disp)
+}
+
+// cell is a helper that converts a column to a display cell, given
+// the cell list returned by layout.  Columns beyond the end of the
+// line are assumed to occupy one display cell each.
+func cell(cells []int, col int) int {
+	if col < 1 {
+		return 0
+	} else if col <= len(cells) {
+		return cells[col-1]
+	}
+
+	return cells[len(cells)-1] + col - len(cells)
+}
+
+// SourceRegistry is a registry of Source objects, keyed by file name.
+// A registry retains the text of sources, allowing errors to be
+// rendered with a snippet of the source text that caused them.
+type SourceRegistry struct {
+	mu      sync.Mutex         // Protects the sources
+	sources map[string]*Source // The registered sources
+}
+
+// NewSourceRegistry constructs a new, empty SourceRegistry.
+func NewSourceRegistry() *SourceRegistry {
+	return &SourceRegistry{
+		sources: map[string]*Source{},
+	}
+}
+
+// open creates a new, empty Source and registers it, replacing any
+// existing Source with the same file name.
+func (r *SourceRegistry) open(file string, tabstop int) *Source {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	src := newSource(file, tabstop)
+	r.sources[file] = src
+
+	return src
+}
+
+// Add registers the text of a file.  This may be used to register
+// sources that are not scanned with a FileScanner.  The text should
+// use single newlines as line endings.
+func (r *SourceRegistry) Add(file, text string, tabstop int) *Source {
+	src := r.open(file, tabstop)
+	for _, ch := range text {
+		src.append(ch)
+	}
+
+	return src
+}
+
+// Source looks up the Source for a given file name.  If the file has
+// not been registered, nil is returned.
+func (r *SourceRegistry) Source(file string) *Source {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.sources[file]
+}
+
+// Render renders an error as a diagnostic.  The first line of the
+// diagnostic is the error message; if the error carries a
+// FileLocation (as reported by LocationOf, possibly wrapped in an
+// IncludeLocation) and the text of the file has been retained, this
+// is followed by the lines of source text covered by the location,
+// with the covered range underlined by a caret and tildes.  The
+// result does not end in a newline.
+func (r *SourceRegistry) Render(err error) string {
+	buf := &bytes.Buffer{}
+	buf.WriteString(err.Error())
+
+	// Select the location and the source
+	tmp := LocationOf(err)
+	if il, ok := tmp.(IncludeLocation); ok {
+		tmp = il.Loc
+	}
+	loc, ok := tmp.(FileLocation)
+	if !ok {
+		return buf.String()
+	}
+	src := r.Source(loc.File)
+	if src == nil {
+		return buf.String()
+	}
+
+	// Figure out the last line to display; a location ending at
+	// the beginning of a line doesn't include that line
+	last := loc.E.L
+	if last > loc.B.L && loc.E.C <= 1 {
+		last--
+	}
+	width := len(strconv.Itoa(last))
+
+	// Render the lines
+	fmt.Fprintf(buf, "\n%*s |", width, "")
+	for ln := loc.B.L; ln <= last; ln++ {
+		line, ok := src.Line(ln)
+		if !ok && ln > loc.B.L {
+			break
+		}
+		text, cells := src.layout(line)
+
+		// Select the range of cells to underline
+		begin, end := 0, cells[len(cells)-1]
+		if ln == loc.B.L {
+			begin = cell(cells, loc.B.C)
+		}
+		if ln == loc.E.L {
+			end = cell(cells, loc.E.C)
+		}
+		if end <= begin {
+			end = begin + 1
+		}
+
+		// Select the underline
+		mark := strings.Repeat("~", end-begin)
+		if ln == loc.B.L {
+			mark = "^" + mark[1:]
+		}
+
+		fmt.Fprintf(buf, "\n%*d | %s", width, ln, text)
+		fmt.Fprintf(buf, "\n%*s | %s%s", width, "", strings.Repeat(" ", begin), mark)
+	}
+
+	return buf.String()
+}
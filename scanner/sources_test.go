@@ -0,0 +1,314 @@
+// Copyright (c) 2020 Kevin L. Mitchell
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you
+// may not use this file except in compliance with the License.  You
+// may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scanner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSource(t *testing.T) {
+	result := newSource("file", 4)
+
+	assert.Equal(t, "file", result.File)
+	assert.Equal(t, 4, result.TabStop)
+	assert.Equal(t, []int{0}, result.lines)
+	assert.Equal(t, "", result.Text())
+}
+
+func TestSourceAppend(t *testing.T) {
+	obj := newSource("file", 8)
+
+	for _, ch := range "ab\ncd\n" {
+		obj.append(ch)
+	}
+
+	assert.Equal(t, "ab\ncd\n", obj.Text())
+	assert.Equal(t, []int{0, 3, 6}, obj.lines)
+}
+
+func TestSourceLinesTerminated(t *testing.T) {
+	obj := newSource("file", 8)
+	for _, ch := range "ab\ncd\n" {
+		obj.append(ch)
+	}
+
+	result := obj.Lines()
+
+	assert.Equal(t, 2, result)
+}
+
+func TestSourceLinesUnterminated(t *testing.T) {
+	obj := newSource("file", 8)
+	for _, ch := range "ab\ncd" {
+		obj.append(ch)
+	}
+
+	result := obj.Lines()
+
+	assert.Equal(t, 2, result)
+}
+
+func TestSourceLine(t *testing.T) {
+	obj := newSource("file", 8)
+	for _, ch := range "ab\ncd\nef" {
+		obj.append(ch)
+	}
+
+	for i, expected := range []string{"ab", "cd", "ef"} {
+		result, ok := obj.Line(i + 1)
+
+		assert.True(t, ok)
+		assert.Equal(t, expected, result)
+	}
+}
+
+func TestSourceLineMissing(t *testing.T) {
+	obj := newSource("file", 8)
+	for _, ch := range "ab\ncd\n" {
+		obj.append(ch)
+	}
+
+	for _, n := range []int{0, 3, 4} {
+		result, ok := obj.Line(n)
+
+		assert.False(t, ok)
+		assert.Equal(t, "", result)
+	}
+}
+
+func TestSourceLayoutBase(t *testing.T) {
+	obj := newSource("file", 8)
+
+	text, cells := obj.layout("abc")
+
+	assert.Equal(t, "abc", text)
+	assert.Equal(t, []int{0, 1, 2, 3}, cells)
+}
+
+func TestSourceLayoutTab(t *testing.T) {
+	obj := newSource("file", 4)
+
+	text, cells := obj.layout("a\tb")
+
+	assert.Equal(t, "a   b", text)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, cells)
+}
+
+func TestSourceLayoutFormFeed(t *testing.T) {
+	obj := newSource("file", 8)
+
+	text, cells := obj.layout("\fab")
+
+	assert.Equal(t, "ab", text)
+	assert.Equal(t, []int{0, 1, 2}, cells)
+}
+
+func TestSourceLayoutWide(t *testing.T) {
+	obj := newSource("file", 8)
+
+	text, cells := obj.layout("a\u4e2db")
+
+	assert.Equal(t, "a\u4e2db", text)
+	assert.Equal(t, []int{0, 1, 3, 4}, cells)
+}
+
+func TestSourceLayoutUnitUTF16(t *testing.T) {
+	obj := newSource("file", 8)
+	obj.Unit = ColumnUTF16
+
+	text, cells := obj.layout("a\U0001f600b")
+
+	assert.Equal(t, "a\U0001f600b", text)
+	assert.Equal(t, []int{0, 1, 1, 3, 4}, cells)
+}
+
+func TestCell(t *testing.T) {
+	cells := []int{0, 1, 1, 2}
+
+	assert.Equal(t, 0, cell(cells, 0))
+	assert.Equal(t, 0, cell(cells, 1))
+	assert.Equal(t, 1, cell(cells, 3))
+	assert.Equal(t, 2, cell(cells, 4))
+	assert.Equal(t, 4, cell(cells, 6))
+}
+
+func TestNewSourceRegistry(t *testing.T) {
+	result := NewSourceRegistry()
+
+	assert.Equal(t, &SourceRegistry{
+		sources: map[string]*Source{},
+	}, result)
+}
+
+func TestSourceRegistryOpen(t *testing.T) {
+	obj := NewSourceRegistry()
+	old := obj.Add("file", "old", 8)
+
+	result := obj.open("file", 4)
+
+	assert.NotSame(t, old, result)
+	assert.Same(t, result, obj.sources["file"])
+	assert.Equal(t, "", result.Text())
+	assert.Equal(t, 4, result.TabStop)
+}
+
+func TestSourceRegistryAdd(t *testing.T) {
+	obj := NewSourceRegistry()
+
+	result := obj.Add("file", "ab\ncd", 4)
+
+	assert.Same(t, result, obj.sources["file"])
+	assert.Equal(t, "ab\ncd", result.Text())
+	assert.Equal(t, 4, result.TabStop)
+}
+
+func TestSourceRegistrySource(t *testing.T) {
+	obj := NewSourceRegistry()
+	src := obj.Add("file", "text", 8)
+
+	assert.Same(t, src, obj.Source("file"))
+	assert.Nil(t, obj.Source("other"))
+}
+
+func TestSourceRegistryRenderNoLocation(t *testing.T) {
+	obj := NewSourceRegistry()
+	obj.Add("file", "text", 8)
+
+	result := obj.Render(assert.AnError)
+
+	assert.Equal(t, assert.AnError.Error(), result)
+}
+
+func TestSourceRegistryRenderNoSource(t *testing.T) {
+	obj := NewSourceRegistry()
+	err := LocationError(FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 1},
+		E:    FilePos{L: 1, C: 2},
+	}, assert.AnError)
+
+	result := obj.Render(err)
+
+	assert.Equal(t, err.Error(), result)
+}
+
+func TestSourceRegistryRenderOneLine(t *testing.T) {
+	obj := NewSourceRegistry()
+	obj.Add("file", "first\nlet x = y\nlast\n", 8)
+	err := LocationError(FileLocation{
+		File: "file",
+		B:    FilePos{L: 2, C: 5},
+		E:    FilePos{L: 2, C: 8},
+	}, assert.AnError)
+
+	result := obj.Render(err)
+
+	assert.Equal(t, strings.Join([]string{
+		err.Error(),
+		"  |",
+		"2 | let x = y",
+		"  |     ^~~",
+	}, "\n"), result)
+}
+
+func TestSourceRegistryRenderIncluded(t *testing.T) {
+	obj := NewSourceRegistry()
+	obj.Add("file", "first\nlet x = y\nlast\n", 8)
+	err := LocationError(IncludeLocation{
+		Loc: FileLocation{
+			File: "file",
+			B:    FilePos{L: 2, C: 5},
+			E:    FilePos{L: 2, C: 8},
+		},
+		Stack: []Location{FileLocation{
+			File: "other",
+			B:    FilePos{L: 10, C: 5},
+			E:    FilePos{L: 10, C: 6},
+		}},
+	}, assert.AnError)
+
+	result := obj.Render(err)
+
+	assert.Equal(t, strings.Join([]string{
+		err.Error(),
+		"  |",
+		"2 | let x = y",
+		"  |     ^~~",
+	}, "\n"), result)
+}
+
+func TestSourceRegistryRenderZeroWidth(t *testing.T) {
+	obj := NewSourceRegistry()
+	obj.Add("file", "abc\n", 8)
+	err := LocationError(FileLocation{
+		File: "file",
+		B:    FilePos{L: 2, C: 1},
+		E:    FilePos{L: 2, C: 1},
+	}, assert.AnError)
+
+	result := obj.Render(err)
+
+	assert.Equal(t, strings.Join([]string{
+		err.Error(),
+		"  |",
+		"2 | ",
+		"  | ^",
+	}, "\n"), result)
+}
+
+func TestSourceRegistryRenderTabs(t *testing.T) {
+	obj := NewSourceRegistry()
+	obj.Add("file", "\tx = y\n", 4)
+	err := LocationError(FileLocation{
+		File: "file",
+		B:    FilePos{L: 1, C: 5},
+		E:    FilePos{L: 1, C: 6},
+	}, assert.AnError)
+
+	result := obj.Render(err)
+
+	assert.Equal(t, strings.Join([]string{
+		err.Error(),
+		"  |",
+		"1 |     x = y",
+		"  |     ^",
+	}, "\n"), result)
+}
+
+func TestSourceRegistryRenderMultiLine(t *testing.T) {
+	obj := NewSourceRegistry()
+	obj.Add("file", "a\n\n\n\n\n\n\n\nfoo(bar,\n  baz\n)\nnext\n", 8)
+	err := LocationError(FileLocation{
+		File: "file",
+		B:    FilePos{L: 9, C: 4},
+		E:    FilePos{L: 12, C: 1},
+	}, assert.AnError)
+
+	result := obj.Render(err)
+
+	assert.Equal(t, strings.Join([]string{
+		err.Error(),
+		"   |",
+		" 9 | foo(bar,",
+		"   |    ^~~~~",
+		"10 |   baz",
+		"   | ~~~~~",
+		"11 | )",
+		"   | ~",
+	}, "\n"), result)
+}